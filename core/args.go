@@ -0,0 +1,157 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// SplitArgs splits s on whitespace into arguments, honoring single quotes,
+// double quotes and backslash escapes the way a basic shell would
+//
+//	ex: args, err := SplitArgs(`-tags "dev debug" -race`) // [-tags, dev debug, -race]
+func SplitArgs(s string) ([]string, error) {
+
+	args := []string{}
+
+	var current strings.Builder
+	var quote rune   // the active quote character, if any
+	inArg := false   // true once the current argument has started
+	escaped := false // true if the previous rune was a backslash
+
+	for _, r := range s {
+
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote in arguments")
+	}
+
+	if escaped {
+		return nil, errors.New("trailing backslash in arguments")
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// buildArgs returns BuildArgs with BuildTags, LDFlags and any injected
+// version merged in
+func (b *Build) buildArgs() []string {
+
+	args := slices.Clone(b.BuildArgs)
+
+	if len(b.BuildTags) > 0 {
+		args = mergeFlag(args, "-tags", strings.Join(b.BuildTags, ","), ",")
+	}
+
+	if b.LDFlags != "" {
+		args = mergeFlag(args, "-ldflags", b.LDFlags, " ")
+	}
+
+	if b.InjectVersion {
+		args = mergeFlag(args, "-ldflags", b.versionLDFlags(), " ")
+	}
+
+	return args
+}
+
+// mergeFlag joins value onto an existing flag in args using sep, otherwise
+// the flag is inserted after the first argument (the go subcommand) so that
+// any trailing package paths stay last
+//
+//	ex: mergeFlag([]string{"build", "-tags", "a"}, "-tags", "b", ",") // [build -tags a,b]
+func mergeFlag(args []string, flag, value, sep string) []string {
+
+	for i, arg := range args {
+
+		// -flag value or --flag value
+		if arg == flag || arg == "-"+flag {
+			if i+1 < len(args) {
+				args[i+1] = args[i+1] + sep + value
+				return args
+			}
+			return append(args, value)
+		}
+
+		// -flag=value or --flag=value
+		for _, prefix := range []string{flag + "=", "-" + flag + "="} {
+			if existing, ok := strings.CutPrefix(arg, prefix); ok {
+				args[i] = prefix + existing + sep + value
+				return args
+			}
+		}
+	}
+
+	at := min(1, len(args))
+	return slices.Insert(args, at, flag, value)
+}
+
+// shellLine renders a command as one line a POSIX shell runs the same way,
+// for reproducing a build or run by hand: a cd into dir when set, then env
+// and the arguments, quoting only what needs it. Later env values for a key
+// replace earlier ones, like mergeEnv.
+//
+//	ex: shellLine("build", []string{"PORT=8081"}, []string{"./app", "--name", "my app"}) // cd build && PORT=8081 ./app --name 'my app'
+func shellLine(dir string, env []string, args []string) string {
+
+	words := []string{}
+	if dir != "" {
+		words = append(words, "cd", shellWord(dir), "&&")
+	}
+
+	// keep each key once, where its last value was given
+	for i, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		if !slices.ContainsFunc(env[i+1:], func(later string) bool { return strings.HasPrefix(later, key+"=") }) {
+			// only the value is quoted, a quoted key is no longer an assignment
+			words = append(words, key+"="+shellWord(value))
+		}
+	}
+
+	for _, arg := range args {
+		words = append(words, shellWord(arg))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// shellWord returns s as is when a POSIX shell would read it unchanged,
+// otherwise single quoted
+func shellWord(s string) string {
+
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-") == "" {
+		return s
+	}
+
+	return shellQuote(s)
+}
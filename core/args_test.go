@@ -0,0 +1,96 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", []string{}},
+		{"   ", []string{}},
+		{"-race -v", []string{"-race", "-v"}},
+		{" \t-race\n -v ", []string{"-race", "-v"}},
+		{`-tags "dev debug"`, []string{"-tags", "dev debug"}},
+		{`-ldflags '-X main.version=1 -s'`, []string{"-ldflags", "-X main.version=1 -s"}},
+		{`-tags=dev" "debug`, []string{"-tags=dev debug"}},
+		{`""`, []string{""}},
+		{`a "" b`, []string{"a", "", "b"}},
+		{`dev\ debug`, []string{"dev debug"}},
+		{`"say \"hi\""`, []string{`say "hi"`}},
+		{`'no \escape'`, []string{`no \escape`}},
+		{`"it's"`, []string{"it's"}},
+		{`'say "hi"'`, []string{`say "hi"`}},
+		{`\\`, []string{`\`}},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitArgs(tt.input)
+		if err != nil {
+			t.Errorf("SplitArgs(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("SplitArgs(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{`"open`, `'open`, `trailing\`} {
+		if _, err := SplitArgs(bad); err == nil {
+			t.Errorf("SplitArgs(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestMergeFlag(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		args  []string
+		flag  string
+		value string
+		sep   string
+		want  []string
+	}{
+		{"inserted after the subcommand", []string{"build", "./cmd/app"}, "-tags", "dev", ",", []string{"build", "-tags", "dev", "./cmd/app"}},
+		{"inserted into empty args", nil, "-tags", "dev", ",", []string{"-tags", "dev"}},
+		{"joined onto separate value", []string{"build", "-tags", "cli", "."}, "-tags", "dev", ",", []string{"build", "-tags", "cli,dev", "."}},
+		{"joined onto double dash", []string{"build", "--tags", "cli"}, "-tags", "dev", ",", []string{"build", "--tags", "cli,dev"}},
+		{"joined onto equals", []string{"build", "-tags=cli", "."}, "-tags", "dev", ",", []string{"build", "-tags=cli,dev", "."}},
+		{"joined onto double dash equals", []string{"build", "--ldflags=-s"}, "-ldflags", "-w", " ", []string{"build", "--ldflags=-s -w"}},
+		{"flag without a value", []string{"build", "-tags"}, "-tags", "dev", ",", []string{"build", "-tags", "dev"}},
+		{"prefix of another flag left alone", []string{"build", "-tagsx", "a"}, "-tags", "dev", ",", []string{"build", "-tags", "dev", "-tagsx", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeFlag(slices.Clone(tt.args), tt.flag, tt.value, tt.sep)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("mergeFlag(%q, %q, %q) = %q, want %q", tt.args, tt.flag, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildArgsMergeConfigFields(t *testing.T) {
+
+	b := &Build{
+		BuildArgs: []string{"build", "-tags", "cli", "-ldflags=-X main.mode=flag", "-o", "build/"},
+		BuildTags: []string{"dev", "debug"},
+		LDFlags:   "-s -w",
+	}
+
+	// flags written in buildArgs, like --build-args-append adds, come first
+	// and the config's buildTags and ldFlags join them rather than replace
+	want := []string{"build", "-tags", "cli,dev,debug", "-ldflags=-X main.mode=flag -s -w", "-o", "build/"}
+	if got := b.buildArgs(); !slices.Equal(got, want) {
+		t.Errorf("buildArgs = %q, want %q", got, want)
+	}
+	if b.BuildArgs[2] != "cli" {
+		t.Errorf("buildArgs modified BuildArgs: %q", b.BuildArgs)
+	}
+}
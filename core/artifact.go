@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Windows holds a lock on a running executable, so a rebuild can fail if the
+// previous run process hasn't fully released it. Start waits for the process
+// to exit and buildUnlocked retries while the artifact is still locked.
+
+// lockedRetries is how many times a build is retried on a locked artifact
+const lockedRetries = 5
+
+// lockedDelay is the pause between locked artifact checks
+const lockedDelay = 500 * time.Millisecond
+
+// buildUnlocked runs BuildContext, retrying on windows while the run artifact
+// is still held by an exiting process
+func (b *Build) buildUnlocked(ctx context.Context) error {
+
+	err := b.BuildContext(ctx)
+
+	for attempt := 1; err != nil && attempt <= lockedRetries && b.artifactLocked(); attempt++ {
+		b.log().Warn("build artifact locked", "name", b.Name, "artifact", b.artifactPath(), "attempt", attempt)
+		select {
+		case <-time.After(lockedDelay):
+		case <-ctx.Done():
+			return err
+		}
+		err = b.BuildContext(ctx)
+	}
+
+	return err
+}
+
+// artifactPath guesses the executable produced by the build from RunCmd and
+// RunDir, returning an empty string when RunCmd is looked up from PATH
+//
+//	ex: "./webserver" in "build" => "build/webserver.exe" on windows
+func (b *Build) artifactPath() string {
+
+	runCmd := filepath.FromSlash(b.RunCmd)
+
+	// bare names are resolved from PATH and are not ours to replace
+	if !strings.ContainsRune(runCmd, filepath.Separator) {
+		return ""
+	}
+
+	path := runCmd
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.FromSlash(b.RunDir), path)
+	}
+
+	if runtime.GOOS == "windows" && filepath.Ext(path) == "" {
+		path += ".exe"
+	}
+
+	return path
+}
+
+// artifactLocked reports whether the run artifact exists but can't be opened
+// for writing, which on windows means a process still holds it
+func (b *Build) artifactLocked() bool {
+
+	if runtime.GOOS != "windows" {
+		return false
+	}
+
+	path := b.artifactPath()
+	if path == "" {
+		return false
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err == nil {
+		file.Close()
+		return false
+	}
+
+	return !errors.Is(err, fs.ErrNotExist)
+}
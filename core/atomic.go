@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// A build writing straight over the run artifact can leave a half-written
+// executable behind when it fails or is interrupted, and the next launch runs
+// it. With AtomicOutput set the -o in buildArgs is pointed at a temporary file
+// beside the artifact, which is renamed over it only once the build succeeds.
+// A rename within one directory never shows a partial file.
+
+// stageOutput rewrites the -o in args to a temporary file, returning the new
+// args and a finish func that moves the file into place when the build
+// succeeded or removes it otherwise, returning the build's final error
+//
+//	ex: args, finish := b.stageOutput(args)
+func (b *Build) stageOutput(args []string) ([]string, func(error) error) {
+
+	keep := func(err error) error { return err }
+
+	if b.Container != nil || b.Remote != nil {
+		b.log().Warn("atomicOutput only applies to local builds", "name", b.Name)
+		return args, keep
+	}
+
+	target := b.artifactPath()
+	if out, isDir, ok := b.outputPath(); ok && !isDir && b.OutDir == "" {
+		target = out
+	}
+
+	index := slices.IndexFunc(args, func(arg string) bool { return arg == "-o" || strings.HasPrefix(arg, "-o=") })
+	if target == "" || index < 0 || (args[index] == "-o" && index+1 == len(args)) {
+		b.log().Warn("atomicOutput needs a -o in buildArgs and a runCmd path to find the artifact", "name", b.Name)
+		return args, keep
+	}
+
+	// the build runs in BuildDir, so hand it an absolute path
+	staged, err := filepath.Abs(filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".tmp"))
+	if err != nil {
+		b.log().Warn("atomicOutput", "name", b.Name, "error", err)
+		return args, keep
+	}
+
+	args = slices.Clone(args)
+	if args[index] == "-o" {
+		args[index+1] = staged
+	} else {
+		args[index] = "-o=" + staged
+	}
+
+	finish := func(err error) error {
+		if err != nil {
+			os.Remove(staged)
+			return err
+		}
+
+		err = replace(staged, target)
+		if err != nil {
+			os.Remove(staged)
+			return fmt.Errorf("atomicOutput: %w", err)
+		}
+
+		b.log().Debug("atomicOutput swapped", "name", b.Name, "artifact", target)
+		return nil
+	}
+
+	return args, finish
+}
+
+// replace renames staged over target. Windows refuses to replace an
+// executable that is still running, like the serving blue/green instance,
+// but does allow renaming it, so the old file is moved aside first.
+func replace(staged, target string) error {
+
+	err := os.Rename(staged, target)
+	if err == nil || runtime.GOOS != "windows" {
+		return err
+	}
+
+	old := target + ".old"
+	os.Remove(old)
+	if os.Rename(target, old) != nil {
+		return err
+	}
+
+	return os.Rename(staged, target)
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicAuth requires HTTP Basic credentials on the reverse proxy or static
+// server, a light gate for sharing a dev server over a tunnel. Set either
+// Password or PasswordHash, a bcrypt hash of the password like one from
+// "htpasswd -nbB user pw", to keep the plain password out of the config.
+//
+//	ex: {"username": "dev", "passwordHash": "$2a$10$9HwXYevnI/D5iBmRwZL.C.QMS2dmzGh5FP56GjXxFYqZOhPTyX7Hy"}
+type BasicAuth struct {
+	Username     string `json:"username"`
+	Password     string `json:"password,omitzero"`
+	PasswordHash string `json:"passwordHash,omitzero"`
+
+	// Deprecated: an unsalted digest is easily reversed from a leaked
+	// config, use PasswordHash. Configs setting it are rejected on load.
+	PasswordSHA256 string `json:"passwordSHA256,omitzero"`
+
+	// Realm is shown by the browser's login prompt, default go-live-reload
+	Realm string `json:"realm,omitzero"`
+}
+
+// check rejects credentials that would let anyone in or can never match,
+// a nil BasicAuth is fine
+//
+//	ex: err := c.BasicAuth.check()
+func (a *BasicAuth) check() error {
+
+	switch {
+	case a == nil:
+		return nil
+	case a.PasswordSHA256 != "":
+		return errors.New("basicAuth passwordSHA256 is no longer supported, set passwordHash to a bcrypt hash instead")
+	case a.Username == "":
+		return errors.New("basicAuth needs a username")
+	case a.Password == "" && a.PasswordHash == "":
+		return errors.New("basicAuth needs a password or passwordHash")
+	case a.Password != "" && a.PasswordHash != "":
+		return errors.New("basicAuth sets both password and passwordHash, pick one")
+	}
+
+	if a.PasswordHash != "" {
+		if _, err := bcrypt.Cost([]byte(a.PasswordHash)); err != nil {
+			return errors.New("basicAuth passwordHash must be a bcrypt hash: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// wrap returns next guarded by the credentials, a nil BasicAuth returns next
+func (a *BasicAuth) wrap(next http.Handler, log *slog.Logger) http.Handler {
+
+	if a == nil {
+		return next
+	}
+
+	// never serve openly on credentials check rejects
+	if err := a.check(); err != nil {
+		log.Error("basic-auth", "error", err, "refusing", "every request")
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "basic auth misconfigured", http.StatusInternalServerError)
+		})
+	}
+
+	realm := a.Realm
+	if realm == "" {
+		realm = "go-live-reload"
+	}
+
+	// compare digests so every comparison takes the same time regardless of
+	// the length of the guess
+	user := sha256.Sum256([]byte(a.Username))
+	pass := sha256.Sum256([]byte(a.Password))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		username, password, ok := r.BasicAuth()
+		gotUser := sha256.Sum256([]byte(username))
+
+		userOK := subtle.ConstantTimeCompare(user[:], gotUser[:]) == 1
+
+		var passOK bool
+		if a.PasswordHash != "" {
+			passOK = bcrypt.CompareHashAndPassword([]byte(a.PasswordHash), []byte(password)) == nil
+		} else {
+			gotPass := sha256.Sum256([]byte(password))
+			passOK = subtle.ConstantTimeCompare(pass[:], gotPass[:]) == 1
+		}
+
+		if !ok || !userOK || !passOK {
+			log.Debug("basic-auth rejected", "remote", r.RemoteAddr, "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasicAuthCheck(t *testing.T) {
+
+	hash := "$2a$10$9HwXYevnI/D5iBmRwZL.C.QMS2dmzGh5FP56GjXxFYqZOhPTyX7Hy" // secret
+
+	tests := []struct {
+		name string
+		auth *BasicAuth
+		ok   bool
+	}{
+		{"unset", nil, true},
+		{"password", &BasicAuth{Username: "dev", Password: "secret"}, true},
+		{"hash", &BasicAuth{Username: "dev", PasswordHash: hash}, true},
+		{"empty password", &BasicAuth{Username: "dev"}, false},
+		{"no username", &BasicAuth{Password: "secret"}, false},
+		{"both", &BasicAuth{Username: "dev", Password: "secret", PasswordHash: hash}, false},
+		{"not bcrypt", &BasicAuth{Username: "dev", PasswordHash: "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"}, false},
+		{"sha256", &BasicAuth{Username: "dev", PasswordSHA256: "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.auth.check(); (err == nil) != tt.ok {
+			t.Errorf("%s: check() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestBasicAuthHash(t *testing.T) {
+
+	auth := &BasicAuth{Username: "dev", PasswordHash: "$2a$10$9HwXYevnI/D5iBmRwZL.C.QMS2dmzGh5FP56GjXxFYqZOhPTyX7Hy"}
+	handler := auth.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		user, pass string
+		want       int
+	}{
+		{"dev", "secret", http.StatusOK},
+		{"dev", "wrong", http.StatusUnauthorized},
+		{"other", "secret", http.StatusUnauthorized},
+		{"dev", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.SetBasicAuth(tt.user, tt.pass)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s:%s got %d, want %d", tt.user, tt.pass, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Blue/green reloads start the freshly built run command on the next port in
+// BlueGreenPorts while the previous instance keeps serving. Once the new
+// instance passes its ReadyCheck, any reverse proxy target following this
+// build group is pointed at the new port and the old instance is shut down
+// after a short drain period. If the build or the ReadyCheck fails, the old
+// instance simply keeps serving.
+
+// blueGreenDrain is how long the retired instance lives after a swap so any
+// in-flight requests can complete
+const blueGreenDrain = 2 * time.Second
+
+// readyTimeout bounds how long we wait on a ReadyCheck before giving up
+const readyTimeout = 30 * time.Second
+
+// readyInterval is the delay between ReadyCheck attempts
+const readyInterval = 100 * time.Millisecond
+
+// errExitedEarly aborts a swap whose instance exits before it is ready
+var errExitedEarly = errors.New("run process exited before it was ready")
+
+// ActivePort returns the blue/green or auto-selected port currently serving
+// for one of the Config's build groups
+//
+//	ex: port, ok := config.ActivePort("webserver")
+func (c *Config) ActivePort(name string) (string, bool) {
+	return c.shared().activePort(name)
+}
+
+// activePort returns the port currently serving for a build group
+func (g *groupState) activePort(name string) (string, bool) {
+	if g == nil {
+		return "", false
+	}
+	port, ok := g.ports.Load(name)
+	if !ok {
+		return "", false
+	}
+	return port.(string), true
+}
+
+// setActivePort points anything following a build group at port
+func (g *groupState) setActivePort(name, port string) {
+	if g != nil {
+		g.ports.Store(name, port)
+	}
+}
+
+// swap starts the next blue/green instance alongside the serving one, waits
+// for it to be ready and then retires the serving instance. The instance
+// reports to exited when it ends, and exiting before it is ready aborts the
+// swap. It returns the cancel func of whichever instance is left serving.
+func (b *Build) swap(ctx context.Context, cancel, serving context.CancelFunc, exited chan<- runExit) context.CancelFunc {
+
+	port := b.BlueGreenPorts[b.swaps%len(b.BlueGreenPorts)]
+	b.Hooks.runStart(b.Name)
+
+	// stop polling ReadyCheck as soon as the new instance dies
+	ready, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+	go func() {
+		err := b.run(ctx, port, nil)
+		abort(errExitedEarly)
+		exited <- runExit{ctx, err}
+	}()
+
+	err := b.waitReady(ready, port)
+	if err != nil {
+		b.log().Error("blue-green not ready", "name", b.Name, "port", port, "error", err)
+		cancel()
+		return serving
+	}
+
+	b.swaps++
+	b.groups.setActivePort(b.Name, port)
+	b.groups.markReady(b.Name)
+	b.log().Info("blue-green swap", "name", b.Name, "port", port)
+
+	// give the old instance time to finish any in-flight requests
+	if serving != nil {
+		time.AfterFunc(blueGreenDrain, serving)
+	}
+
+	return cancel
+}
+
+// waitReady polls the ReadyCheck URL until it answers or readyTimeout passes.
+// If no ReadyCheck is defined the instance is assumed ready.
+func (b *Build) waitReady(ctx context.Context, port string) error {
+
+	if b.ReadyCheck == "" {
+		b.log().Warn("readyCheck not defined", "name", b.Name)
+		return nil
+	}
+
+	if port == "" {
+		port = b.Port
+	}
+	target := strings.ReplaceAll(b.ReadyCheck, "{port}", port)
+
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	tick := time.NewTicker(readyInterval)
+	defer tick.Stop()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < http.StatusInternalServerError {
+				b.log().Debug("ready", "name", b.Name, "target", target, "status", resp.StatusCode)
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-tick.C:
+		}
+	}
+}
+
+// expandPort returns a copy of values with any {port} token replaced by port
+func expandPort(values []string, port string) []string {
+
+	if values == nil {
+		return nil
+	}
+
+	expanded := make([]string, len(values))
+	for i, value := range values {
+		expanded[i] = strings.ReplaceAll(value, "{port}", port)
+	}
+
+	return expanded
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSwapAbortsWhenInstanceExits(t *testing.T) {
+
+	cmd, args := helperCommand()
+	b := &Build{
+		Name:           "bluegreen-crash",
+		RunCmd:         cmd,
+		RunArgs:        args,
+		RunEnv:         []string{"GLR_HELPER_OUTPUT=crash", "GORACE=atexit_sleep_ms=0"},
+		BlueGreenPorts: []string{"1"},
+		ReadyCheck:     "http://127.0.0.1:{port}/", // nothing answers, only the exit ends the wait
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	retired := make(chan struct{})
+	serving := func() { close(retired) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exited := make(chan runExit, 1)
+
+	start := time.Now()
+	left := b.swap(ctx, cancel, serving, exited)
+	if waited := time.Since(start); waited > readyTimeout/2 {
+		t.Errorf("swap waited %s on an instance that crashed", waited)
+	}
+	if b.swaps != 0 {
+		t.Errorf("swaps = %d after a failed swap, want 0", b.swaps)
+	}
+
+	// the failed instance is reported and already stopped
+	select {
+	case exit := <-exited:
+		if exit.ctx.Err() == nil {
+			t.Error("failed instance reported as still wanted")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed instance never reported its exit")
+	}
+
+	// the old instance keeps serving
+	select {
+	case <-retired:
+		t.Fatal("old instance retired by a failed swap")
+	case <-time.After(blueGreenDrain + 500*time.Millisecond):
+	}
+	left()
+	select {
+	case <-retired:
+	default:
+		t.Error("swap did not hand back the serving instance")
+	}
+}
+
+func TestStartWaitsForServingInstance(t *testing.T) {
+
+	addrFile := filepath.Join(t.TempDir(), "addr")
+	cmd, args := helperCommand()
+	b := &Build{
+		Name:           "bluegreen-shutdown",
+		NoBuild:        true,
+		RunCmd:         cmd,
+		RunArgs:        args,
+		RunEnv:         []string{"GLR_HELPER_LISTEN=" + addrFile, "GORACE=atexit_sleep_ms=0"},
+		BlueGreenPorts: []string{"1"},
+		HeartBeat:      Duration(50 * time.Millisecond),
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.Start(ctx, make(chan Change))
+		close(done)
+	}()
+
+	var addr []byte
+	for deadline := time.Now().Add(10 * time.Second); len(addr) == 0; {
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatal("blue/green instance never started")
+		}
+		time.Sleep(20 * time.Millisecond)
+		addr, _ = os.ReadFile(addrFile)
+	}
+
+	cancel()
+	<-done
+
+	// Start only returns once the serving instance is gone
+	if conn, err := net.DialTimeout("tcp", string(addr), time.Second); err == nil {
+		conn.Close()
+		t.Error("blue/green instance still serving after Start returned")
+	}
+}
@@ -1,106 +1,623 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
+	"maps"
+	"math/rand/v2"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
 	"time"
 )
 
+// FallbackHeartBeat is used when a build group has no heartBeat defined
+const FallbackHeartBeat = Duration(1 * time.Second)
+
 // Build is a struct that represents a build and run process
 type Build struct {
-	Name        string        `json:"name,omitzero"`
-	Description string        `json:"description,omitzero"`
-	Match       []string      `json:"match,omitzero"`
-	HeartBeat   time.Duration `json:"heartBeat,omitzero"`
-	BuildCmd    string        `json:"buildCmd,omitzero"`
-	BuildArgs   []string      `json:"buildArgs,omitzero"`
-	BuildEnv    []string      `json:"buildEnv,omitzero"`
-	BuildDir    string        `json:"buildDir,omitzero"`
-	RunCmd      string        `json:"runCmd,omitzero"`
-	RunArgs     []string      `json:"runArgs,omitzero"`
-	RunEnv      []string      `json:"runEnv,omitzero"`
-	RunDir      string        `json:"runDir,omitzero"`
+	Name        string   `json:"name,omitzero"`
+	Description string   `json:"description,omitzero"`
+	Match       []string `json:"match,omitzero"`
+	HeartBeat   Duration `json:"heartBeat,omitzero"`
+	BuildCmd    string   `json:"buildCmd,omitzero"`
+	BuildArgs   []string `json:"buildArgs,omitzero"`
+	BuildEnv    []string `json:"buildEnv,omitzero"`
+	BuildDir    string   `json:"buildDir,omitzero"`
+	RunCmd      string   `json:"runCmd,omitzero"`
+	RunArgs     []string `json:"runArgs,omitzero"`
+	RunEnv      []string `json:"runEnv,omitzero"`
+	RunDir      string   `json:"runDir,omitzero"`
+
+	// HeartBeatJitter spreads each heartbeat by up to this fraction of
+	// itself either way, capped at 0.5, so groups sharing a heartBeat don't
+	// all scan at once
+	//	ex: 0.1
+	HeartBeatJitter float64 `json:"heartBeatJitter,omitzero"`
+
+	// Extensions watch every file with these extensions under WatchDir, in
+	// addition to anything Match finds, see extensions.go
+	//	ex: [".go", ".templ"]
+	Extensions []string `json:"extensions,omitzero"`
+
+	// WatchDir is the directory walked for Extensions, default "."
+	WatchDir string `json:"watchDir,omitzero"`
+
+	// Ignore removes paths matched by Match or Extensions from the watch, a
+	// pattern may match the path, its base name or any parent directory
+	//	ex: ["node_modules", "*_test.go"]
+	Ignore []string `json:"ignore,omitzero"`
+
+	// PrefixOutput prefixes every line of the build and run output with the
+	// group's label, implied when Label or Color is set, see prefix.go
+	PrefixOutput bool `json:"prefixOutput,omitzero"`
+
+	// Label replaces the group name in the output prefix
+	//	ex: "API"
+	Label string `json:"label,omitzero"`
+
+	// Color of the output prefix, one of red, green, yellow, blue, magenta or
+	// cyan, derived from the label when unset
+	//	ex: "cyan"
+	Color string `json:"color,omitzero"`
+
+	// Remote runs the build and run commands on another host over ssh
+	// while watching stays local, see remote.go. Container takes precedence
+	// when both are set.
+	Remote *Remote `json:"remote,omitzero"`
+
+	// ReloadMode "signal" sends ReloadSignal to the run process on a file
+	// change instead of rebuilding and restarting, see signal.go. Manual
+	// reloads still restart.
+	//	ex: "signal"
+	ReloadMode string `json:"reloadMode,omitzero"`
+
+	// ReloadSignal is the signal sent in signal mode, defaults to SIGHUP
+	//	ex: "SIGUSR1"
+	ReloadSignal string `json:"reloadSignal,omitzero"`
+
+	// Sidecars are companion processes started and stopped together with
+	// the run command, see sidecar.go
+	//	ex: [{"name": "css", "runCmd": "npx", "runArgs": ["tailwindcss", "--watch"]}]
+	Sidecars []Sidecar `json:"sidecars,omitzero"`
+
+	// AtomicOutput builds into a temporary file beside the run artifact and
+	// renames it into place only when the build succeeds, see atomic.go
+	AtomicOutput bool `json:"atomicOutput,omitzero"`
+
+	// Timings logs how long each phase of every build took at info rather
+	// than debug level, see timings.go
+	Timings bool `json:"timings,omitzero"`
+
+	// ReportCache logs how many packages a go build or go install compiled
+	// rather than took from the build cache, see cache.go
+	ReportCache bool `json:"reportCache,omitzero"`
+
+	// TriggerFile forces a full reload when its modtime changes, so tools
+	// can reload the group with a touch, see trigger.go
+	//	ex: ".reload"
+	TriggerFile string `json:"triggerFile,omitzero"`
+
+	// BuildLogFile and RunLogFile keep a copy of the build and run output,
+	// rotated once past LogMaxSize bytes (default 10MB) keeping LogMaxBackups
+	// timestamped files (default 3), see logfile.go
+	//	ex: "logs/api.log"
+	BuildLogFile  string `json:"buildLogFile,omitzero"`
+	RunLogFile    string `json:"runLogFile,omitzero"`
+	LogMaxSize    int64  `json:"logMaxSize,omitzero"`
+	LogMaxBackups int    `json:"logMaxBackups,omitzero"`
+
+	// ParseErrors names one of the Parsers used to pick errors out of a
+	// failed build's output and log them one by one, see diagnostics.go
+	//	ex: "go"
+	ParseErrors string `json:"parseErrors,omitzero"`
+
+	// Container runs the build and run commands in a container rather than
+	// on the host, see container.go
+	Container *Container `json:"container,omitzero"`
+
+	// Toolchain fills in buildCmd, buildArgs and match defaults for a known
+	// toolchain (go, make, npm, cargo), see toolchain.go
+	Toolchain string `json:"toolchain,omitzero"`
+
+	// Env is applied to both the build and run commands, with BuildEnv and
+	// RunEnv layered on top and the last value for a key winning
+	//	ex: ["GOOS=linux", "GOARCH=arm64"]
+	Env []string `json:"env,omitzero"`
+
+	// NoBuild skips the build phase so a change relaunches RunCmd directly,
+	// and NoRun skips the run phase for groups that only build, like
+	// codegen, which then wait for the next change
+	NoBuild bool `json:"noBuild,omitzero"`
+	NoRun   bool `json:"noRun,omitzero"`
+
+	// PostReload is a command run in the background each time the run
+	// process is launched, once ReadyCheck answers if set, see postreload.go
+	//	ex: ["xdg-open", "http://localhost:{port}"]
+	PostReload []string `json:"postReload,omitzero"`
+
+	// SettleWindow is how long after each build changes are taken as the
+	// new baseline rather than a reason to restart, see settle.go
+	//	ex: "500ms"
+	SettleWindow Duration `json:"settleWindow,omitzero"`
+
+	// NoInitial skips the initial build and run, the group waits idle for
+	// its first change
+	NoInitial bool `json:"noInitial,omitzero"`
+
+	// StartDelay is waited before the first build and run, to stagger many
+	// groups or wait on an external dependency
+	//	ex: "2s"
+	StartDelay Duration `json:"startDelay,omitzero"`
+
+	// OneShot marks RunCmd as a task that exits on its own rather than a long
+	// running process. A non-zero exit blocks like a failed build until the
+	// next change, a clean exit reruns after OneShotInterval if set.
+	OneShot         bool     `json:"oneShot,omitzero"`
+	OneShotInterval Duration `json:"oneShotInterval,omitzero"`
+
+	// AutoRestart relaunches the run process without a rebuild when it exits
+	// on its own, backing off between attempts if it keeps crashing
+	AutoRestart bool `json:"autoRestart,omitzero"`
+
+	// MaxRestartsPerMinute pauses the group for a cooldown when it restarts
+	// more often than this, zero disables the guard
+	MaxRestartsPerMinute int `json:"maxRestartsPerMinute,omitzero"`
+
+	// Stdin passes the tool's stdin through to the run process, only one build
+	// group may set it since stdin can't be shared
+	Stdin bool `json:"stdin,omitzero"`
+
+	// BuildTimeout bounds how long a build may take before it is stopped and
+	// treated as failed, unset means no limit. It never applies to the run
+	// process, which lives until the next restart or shutdown.
+	//	ex: "2m"
+	BuildTimeout Duration `json:"buildTimeout,omitzero"`
+
+	// StateFile saves the file state of each successful build so the first
+	// build after a launch is skipped when nothing changed, see state.go
+	//	ex: ".cache/webserver.json"
+	StateFile string `json:"stateFile,omitzero"`
+
+	// ShutdownGrace is how long the run process has to exit after being
+	// interrupted on restart or shutdown before it is killed, default 5s
+	ShutdownGrace Duration `json:"shutdownGrace,omitzero"`
+
+	// EnvFile is a dotenv file loaded before every build and run, applied
+	// under Env, BuildEnv and RunEnv
+	//	ex: ".env"
+	EnvFile string `json:"envFile,omitzero"`
+
+	// ReadyCheck is an optional URL polled after the run command starts, the
+	// instance is ready once it answers with a non 5xx response
+	//	ex: "http://localhost:{port}/healthz"
+	ReadyCheck string `json:"readyCheck,omitzero"`
+
+	// Port is the port the run command listens on. It is exported to the run
+	// command as PORT and replaces {port} in runArgs, runEnv and readyCheck,
+	// blue/green ports take over when set. AutoPort picks a free one.
+	//	ex: "8081"
+	Port string `json:"port,omitzero"`
+
+	// BlueGreenPorts enables zero-downtime reloads by alternating the run
+	// command between these ports, see bluegreen.go
+	//	ex: ["8081", "8091"]
+	BlueGreenPorts []string `json:"blueGreenPorts,omitzero"`
+
+	// Compare lists the file attributes that count as a change, any of
+	// "modtime", "size" and "mode", defaults to ["modtime"]
+	//	ex: ["modtime", "size", "mode"]
+	Compare []string `json:"compare,omitzero"`
+
+	// WatchDirsOnly watches the modtimes of the directories holding matched
+	// files instead of every file, far fewer stats on a huge tree. Most
+	// filesystems only bump a directory's modtime when entries are added,
+	// removed or renamed, so in-place edits go unseen.
+	WatchDirsOnly bool `json:"watchDirsOnly,omitzero"`
+
+	// ScanWorkers bounds how many files are stat'd concurrently each
+	// heartbeat, defaults to GOMAXPROCS when unset
+	ScanWorkers int `json:"scanWorkers,omitzero"`
+
+	// NetworkPoll reads watched directories in batches instead of stat'ing
+	// every file, for network filesystems and host mounted volumes, see
+	// netpoll.go
+	//	ex: {"batchSize": 512, "workers": 32}
+	NetworkPoll *NetworkPoll `json:"networkPoll,omitzero"`
+
+	// BuildTags are passed to the build as -tags, merged with any -tags
+	// already present in BuildArgs
+	//	ex: ["dev", "sqlite"]
+	BuildTags []string `json:"buildTags,omitzero"`
+
+	// LDFlags are passed to the build as -ldflags, merged with any -ldflags
+	// already present in BuildArgs
+	//	ex: "-s -w"
+	LDFlags string `json:"ldFlags,omitzero"`
+
+	// InjectVersion resolves the git tag and commit of BuildDir at build time
+	// and passes them to the build with -ldflags -X
+	InjectVersion bool `json:"injectVersion,omitzero"`
+
+	// VersionVar and CommitVar are the -X targets for InjectVersion and
+	// default to main.version and main.commit
+	VersionVar string `json:"versionVar,omitzero"`
+	CommitVar  string `json:"commitVar,omitzero"`
+
+	// Steps are lighter builds run instead of the full build when every
+	// changed glob belongs to the step, see steps.go
+	Steps []BuildStep `json:"steps,omitzero"`
+
+	// OutDir is the directory the build writes into, used to keep the build
+	// output out of the watch when it can't be inferred from a -o flag
+	OutDir string `json:"outDir,omitzero"`
+
+	// Logger receives the group's log output, defaulting to slog.Default()
+	// when nil so embedders can scope or capture it per group
+	Logger *slog.Logger `json:"-"`
+
+	// Hooks are lifecycle callbacks for programs embedding the reloader,
+	// see hooks.go
+	Hooks Hooks `json:"-"`
+
+	swaps    int         // count of successful blue/green swaps
+	excludes []string    // build output paths left out of the watch
+	groups   *groupState // shared with the other groups, see groupstate.go
+
+	// HeartBeat came from the config's DefaultHeartBeat
+	defaultBeat bool
+}
+
+// log returns the group's Logger or slog.Default()
+func (b *Build) log() *slog.Logger {
+	if b.Logger != nil {
+		return b.Logger
+	}
+	return slog.Default()
 }
 
 // Build executes the configured buildCmd with buildArgs and buildEnv variables.
 //
 // ex: err := b.Build()
 func (b *Build) Build() error {
+	return b.BuildContext(context.Background())
+}
+
+// BuildContext is Build, interrupting the build command when ctx is cancelled
+// so a shutdown doesn't leave a compiler running after the tool exits.
+//
+// ex: err := b.BuildContext(ctx)
+func (b *Build) BuildContext(parent context.Context) error {
 
 	if b.BuildCmd == "" {
-		slog.Warn("buildCmd not defined", "name", b.Name, "buildCmd", b.BuildCmd)
+		b.log().Warn("buildCmd not defined", "name", b.Name, "buildCmd", b.BuildCmd)
 		return nil
 	}
 
+	// log how long each phase took however the build ends
+	timer := newPhaseTimer()
+	defer b.logTimings(timer)
+
 	// convert any paths to the correct format for the OS
 	b.BuildCmd = filepath.FromSlash(b.BuildCmd)
 	b.BuildDir = filepath.FromSlash(b.BuildDir)
 
-	slog.Info("build execute", "name", b.Name, "buildDir", b.BuildDir, "buildCmd", b.BuildCmd, "buildArgs", b.BuildArgs, "buildEnv", b.BuildEnv)
+	buildArgs := b.buildArgs()
+
+	reportCache := b.reportsCache(buildArgs)
+	if reportCache {
+		buildArgs = withVerbose(buildArgs)
+	}
+
+	// build beside the artifact and swap it in only on success
+	finish := func(err error) error { return err }
+	if b.AtomicOutput {
+		buildArgs, finish = b.stageOutput(buildArgs)
+	}
+
+	b.log().Info("build execute", "name", b.Name, "buildDir", b.BuildDir, "buildCmd", b.BuildCmd, "buildArgs", buildArgs, "env", b.Env, "buildEnv", b.BuildEnv)
 
 	start := time.Now()
 
-	cmd := exec.Command(b.BuildCmd, b.BuildArgs...)
+	envFile, err := b.envFile()
+	if err != nil {
+		b.log().Error("build envFile", "name", b.Name, "error", err)
+		return err
+	}
+
+	// the timeout bounds this build only, the run process gets its own
+	// context from Start and lives until the next restart or shutdown
+	ctx := parent
+	if b.BuildTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(b.BuildTimeout))
+		defer cancel()
+	}
+
+	var cmd *exec.Cmd
+
+	if b.Container != nil {
+		name, args, err := b.Container.command(b.BuildCmd, buildArgs, b.BuildDir, slices.Concat(envFile, b.Env, b.BuildEnv), nil, false)
+		if err != nil {
+			b.log().Error("build container", "name", b.Name, "error", err)
+			return err
+		}
+		cmd = exec.CommandContext(ctx, name, args...)
+	} else if b.Remote != nil {
+		name, args, err := b.Remote.command(b.BuildCmd, buildArgs, b.BuildDir, slices.Concat(envFile, b.Env, b.BuildEnv), false)
+		if err != nil {
+			b.log().Error("build remote", "name", b.Name, "error", err)
+			return err
+		}
+		cmd = exec.CommandContext(ctx, name, args...)
+	} else {
+		cmd = exec.CommandContext(ctx, b.BuildCmd, buildArgs...)
+		cmd.Dir = b.BuildDir
+
+		// combine the current process environment with the provided environs
+		cmd.Env = mergeEnv(envFile, b.Env, b.BuildEnv)
+	}
+
+	// a copy-pasteable command for reproducing the build by hand, in the
+	// message since attribute values get escaped; container and remote
+	// commands carry their env in the arguments
+	layered := slices.Concat(envFile, b.Env, b.BuildEnv)
+	if b.Container != nil || b.Remote != nil {
+		layered = nil
+	}
+	b.log().Debug("build command for " + b.Name + ": " + shellLine(cmd.Dir, layered, cmd.Args))
+
+	// give a timed out build the same chance to clean up as a run process
+	cmd.Cancel = func() error { return interrupt(cmd.Process) }
+	cmd.WaitDelay = b.shutdownGrace()
 
-	cmd.Dir = b.BuildDir
+	cmd.Stdout, cmd.Stderr = b.outputs()
+	cmd.Stdout, cmd.Stderr = b.logTo(b.BuildLogFile, "build", cmd.Stdout, cmd.Stderr)
 
-	// combine the current process environment with the provided environs
-	if b.BuildEnv != nil {
-		cmd.Env = append(os.Environ(), b.BuildEnv...)
+	// parsed output is passed on after the build, past the overlay capture
+	stdout := cmd.Stdout
+
+	// keep the end of the output for the live reload error overlay
+	captured := &tailBuffer{max: liveOutputMax}
+	cmd.Stdout, cmd.Stderr = io.MultiWriter(cmd.Stdout, captured), io.MultiWriter(cmd.Stderr, captured)
+
+	// capture the output to pick errors out of it when asked
+	parse, parsing := Parsers[b.ParseErrors]
+	var output bytes.Buffer
+	if parsing {
+		cmd.Stdout, cmd.Stderr = &output, &output
+	} else if b.ParseErrors != "" {
+		b.log().Warn("parseErrors unknown parser, output left as is", "name", b.Name, "parseErrors", b.ParseErrors)
 	}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// keep the -v package list off the console, anything else is passed on
+	stderr := cmd.Stderr
+	var verbose bytes.Buffer
+	if reportCache {
+		cmd.Stderr = &verbose
+	}
+
+	timer.mark("prepare")
+	err = cmd.Run()
+	timer.mark("command")
+	if b.AtomicOutput {
+		err = finish(err)
+		timer.mark("finish")
+	}
 
-	err := cmd.Run()
+	var compiled []string
+	if reportCache {
+		var rest []byte
+		compiled, rest = splitVerbose(verbose.Bytes())
+		stderr.Write(rest)
+	}
+	if parsing {
+		captured.Write(output.Bytes())
+		b.reportBuildOutput(parse, output.Bytes(), err != nil, stdout)
+	}
+	if reportCache || parsing {
+		timer.mark("report")
+	}
 	if err != nil {
-		slog.Error("build", "name", b.Name, "error", err)
+		switch {
+		case parent.Err() != nil:
+			err = fmt.Errorf("build cancelled: %w", err)
+		case ctx.Err() != nil:
+			err = fmt.Errorf("build timed out after %s: %w", b.BuildTimeout, err)
+		}
+		b.log().Error("build", "name", b.Name, "error", err)
+		if parent.Err() == nil {
+			b.groups.publishBuild(b.Name, err, captured.Bytes())
+		}
 		return err
 	}
 
-	slog.Info("build success", "name", b.Name, "duration", time.Since(start))
+	b.groups.publishBuild(b.Name, nil, nil)
+
+	if reportCache {
+		b.log().Debug("build rebuilt packages", "name", b.Name, "packages", compiled)
+		b.log().Info("build success", "name", b.Name, "duration", time.Since(start), "rebuilt", len(compiled), "cached", len(compiled) == 0)
+		return nil
+	}
+
+	b.log().Info("build success", "name", b.Name, "duration", time.Since(start))
 	return nil
 }
 
-// Run executes the configured runCmd with runArgs and runEnv variables.
+// Run executes the configured runCmd with runArgs and runEnv variables,
+// returning the error from the process exiting if any.
 //
-// ex: b.Run(ctx)
-func (b *Build) Run(ctx context.Context) {
+// ex: err := b.Run(ctx)
+func (b *Build) Run(ctx context.Context) error {
+	return b.run(ctx, "", nil)
+}
+
+// run executes the runCmd, replacing any {port} tokens in runArgs and runEnv
+// with port, or Port when empty, and exporting it as PORT. Signals received
+// on sigs are passed on to the process while it runs.
+func (b *Build) run(ctx context.Context, port string, sigs <-chan os.Signal) error {
 
 	if b.RunCmd == "" {
-		slog.Warn("runCmd not defined", "name", b.Name, "runCmd", b.RunCmd)
-		return
+		b.log().Warn("runCmd not defined", "name", b.Name, "runCmd", b.RunCmd)
+		return nil
 	}
 
 	// convert any paths to the correct format for the OS
 	b.RunCmd = filepath.FromSlash(b.RunCmd)
 	b.RunDir = filepath.FromSlash(b.RunDir)
 
-	slog.Info("run execute", "name", b.Name, "runDir", b.RunDir, "runCmd", b.RunCmd, "runArgs", b.RunArgs, "runEnv", b.RunEnv)
+	if port == "" {
+		port = b.Port
+	}
+
+	runArgs := expandPort(b.RunArgs, port)
+	env := expandPort(b.Env, port)
+
+	// frameworks commonly read PORT, anything set in the config still wins
+	if port != "" {
+		env = slices.Concat([]string{"PORT=" + port}, env)
+	}
+	runEnv := expandPort(b.RunEnv, port)
+
+	b.log().Info("run execute", "name", b.Name, "runDir", b.RunDir, "runCmd", b.RunCmd, "runArgs", runArgs, "env", env, "runEnv", runEnv)
+
+	envFile, err := b.envFile()
+	if err != nil {
+		b.log().Error("run envFile", "name", b.Name, "error", err)
+		return err
+	}
+
+	var cmd *exec.Cmd
+
+	if b.Container != nil {
+		name, args, err := b.Container.command(b.RunCmd, runArgs, b.RunDir, slices.Concat(envFile, env, runEnv), expandPort(b.Container.Ports, port), b.Stdin)
+		if err != nil {
+			b.log().Error("run container", "name", b.Name, "error", err)
+			return err
+		}
+		cmd = exec.CommandContext(ctx, name, args...)
+	} else if b.Remote != nil {
+		name, args, err := b.Remote.command(b.RunCmd, runArgs, b.RunDir, slices.Concat(envFile, env, runEnv), true)
+		if err != nil {
+			b.log().Error("run remote", "name", b.Name, "error", err)
+			return err
+		}
+		cmd = exec.CommandContext(ctx, name, args...)
+	} else {
+		cmd = exec.CommandContext(ctx, b.RunCmd, runArgs...)
+		cmd.Dir = b.RunDir
+
+		// combine the current process environment with the provided environs
+		cmd.Env = mergeEnv(envFile, env, runEnv)
+	}
 
-	cmd := exec.CommandContext(ctx, b.RunCmd, b.RunArgs...)
+	// a copy-pasteable command for reproducing the run by hand, in the
+	// message since attribute values get escaped; container and remote
+	// commands carry their env in the arguments
+	layered := slices.Concat(envFile, env, runEnv)
+	if b.Container != nil || b.Remote != nil {
+		layered = nil
+	}
+	b.log().Debug("run command for " + b.Name + ": " + shellLine(cmd.Dir, layered, cmd.Args))
 
-	cmd.Dir = b.RunDir
+	// ask the process to stop when ctx is cancelled, killing it only if it
+	// is still running after the grace period
+	cmd.Cancel = func() error { return interrupt(cmd.Process) }
+	cmd.WaitDelay = b.shutdownGrace()
 
-	// combine the current process environment with the provided environs
-	if b.RunEnv != nil {
-		cmd.Env = append(os.Environ(), b.RunEnv...)
+	// each new process gets the tool's stdin so restarts reattach it
+	if b.Stdin {
+		cmd.Stdin = os.Stdin
 	}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout, cmd.Stderr = b.outputs()
+	cmd.Stdout, cmd.Stderr = b.logTo(b.RunLogFile, "run", cmd.Stdout, cmd.Stderr)
 
-	err := cmd.Run()
+	err = cmd.Start()
+	if err == nil {
+		// on windows the process tree goes with the run process
+		release := killTreeOnClose(cmd, b.log())
+		done := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case sig := <-sigs:
+					if err := cmd.Process.Signal(sig); err != nil {
+						b.log().Warn("run signal", "name", b.Name, "signal", sig, "error", err)
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+		err = cmd.Wait()
+		close(done)
+		release()
+	}
 	if err != nil {
-		slog.Warn("run", "name", b.Name, "error", err)
-		return
+		b.log().Warn("run", "name", b.Name, "exitCode", cmd.ProcessState.ExitCode(), "error", err)
+		return err
 	}
 
-	slog.Info("run success", "name", b.Name)
+	b.log().Info("run success", "name", b.Name)
+	return nil
+}
+
+// defaultShutdownGrace is how long a run process has to exit after being
+// interrupted when ShutdownGrace is not set
+const defaultShutdownGrace = 5 * time.Second
+
+// shutdownGrace returns ShutdownGrace or the default
+func (b *Build) shutdownGrace() time.Duration {
+	if b.ShutdownGrace > 0 {
+		return time.Duration(b.ShutdownGrace)
+	}
+	return defaultShutdownGrace
+}
+
+// interrupt asks a process to exit, windows can't deliver os.Interrupt to
+// another process so it is killed outright
+func interrupt(process *os.Process) error {
+	if runtime.GOOS == "windows" {
+		return process.Kill()
+	}
+	return process.Signal(os.Interrupt)
+}
+
+// autoRestartMin and autoRestartMax bound the backoff between relaunches of
+// a crashed run process, the backoff resets once a process stays up for
+// autoRestartStable
+const (
+	autoRestartMin    = 500 * time.Millisecond
+	autoRestartMax    = 30 * time.Second
+	autoRestartStable = 10 * time.Second
+)
+
+// runExit is what a run process reports to Start when it ends, a cancelled
+// ctx means it was stopped rather than exiting on its own
+type runExit struct {
+	ctx context.Context
+	err error
+}
+
+// Change describes what Watch detected and is sent to Start over the
+// restart channel
+type Change struct {
+	Globs    []string   // the Match globs or Extensions whose files changed
+	Added    []string   // paths that appeared since the last scan
+	Removed  []string   // paths that disappeared since the last scan
+	Modified []string   // paths whose compared attributes changed
+	Manual   bool       // requested by the user rather than a file change
+	Step     *BuildStep // the step covering every changed glob, nil for a full build
+	Detected time.Time  // when Watch saw the change, zero for manual reloads
 }
 
 // Start manages the build and run processes
@@ -108,119 +625,630 @@ func (b *Build) Run(ctx context.Context) {
 // Calling cancel on the parent context will stop the build and run processes;
 // otherwise the restart channel will trigger a rebuild and rerun. If a build
 // fails, the routine halts until it receives a signal from the restart channel.
+// When a change only touches globs covered by one of the Steps, that step runs
+// instead and the run process is left alone, or signalled or restarted without
+// a build depending on the step's ReloadMode. In signal mode the run process
+// is sent ReloadSignal rather than restarted.
 //
-// ex: b.Start(parentContext)
-func (b *Build) Start(parentContext context.Context, restart chan struct{}) {
+// ex: b.Start(parentContext, restart)
+func (b *Build) Start(parentContext context.Context, restart chan Change) {
+
+	b.log().Info("watch start", "name", b.Name, "match", b.Match, "extensions", b.Extensions)
+	b.resolvePort()
+	b.setPhase(phaseStarting)
+
+	// stagger the first build or give an external dependency time to start,
+	// changes seen meanwhile are covered by that first build anyway
+	if b.StartDelay > 0 {
+		b.log().Info("start delayed", "name", b.Name, "startDelay", b.StartDelay)
+		timer := time.NewTimer(time.Duration(b.StartDelay))
+	delay:
+		for {
+			select {
+			case <-parentContext.Done():
+				timer.Stop()
+				b.log().Warn("shutdown signaled", "name", b.Name)
+				return
+			case <-restart:
+			case <-timer.C:
+				break delay
+			}
+		}
+	}
 
-	slog.Info("watch start", "name", b.Name, "match", b.Match)
+	var serving context.CancelFunc // blue/green instance behind the proxy
+	guard := &restartGuard{}       // catches rebuild storms
+	var detected time.Time         // when the change being reloaded was seen
+	var stopped time.Duration      // how long the old process took to exit
+
+	// nothing runs until the first change asks for it
+	if b.NoInitial {
+		b.log().Info("waiting for the first change, noInitial set", "name", b.Name)
+		select {
+		case <-parentContext.Done():
+			b.log().Warn("shutdown signaled", "name", b.Name)
+			return
+		case change := <-restart:
+			b.log().Warn("restart signal", "name", b.Name, "manual", change.Manual, "files", len(change.Added)+len(change.Removed)+len(change.Modified))
+			detected = changeTime(change)
+		}
+	}
+
+	launched := false // whether the loop has built once since the tool started
+
+	// every run process reports here when it ends, blue/green instances keep
+	// serving across builds so they are counted until reaped
+	exited := make(chan runExit)
+	running := 0
+	reap := func() {
+		for ; running > 0; running-- {
+			<-exited
+		}
+	}
 
 	for {
 
-		err := b.Build()
+		// remember what the build is made from, scanned before building so a
+		// file saved mid-build is still seen as changed next launch
+		var state fileState
+		if b.StateFile != "" {
+			state = b.snapshot(b.scan(nil, &quietLog{log: b.log()}))
+		}
+
+		var err error
+		var built time.Duration
+		if b.NoBuild {
+			b.log().Debug("build skipped, noBuild set", "name", b.Name)
+		} else if !launched && b.StateFile != "" && b.unchanged(state) {
+			b.log().Info("build skipped, no changes since the last run", "name", b.Name, "stateFile", b.StateFile)
+		} else {
+			b.Hooks.buildStart(b.Name)
+			b.setPhase(phaseBuilding)
+			buildStart := time.Now()
+			err = b.buildUnlocked(parentContext)
+			b.markBuilt()
+			built = time.Since(buildStart)
+			b.Hooks.buildDone(b.Name, err, built)
+			if err != nil {
+				b.setPhase(phaseFailed)
+			}
+			if err == nil && b.StateFile != "" {
+				b.saveState(state)
+			}
+		}
+		launched = true
+
 		if err != nil {
-			slog.Error("watch", "name", b.Name, "error", err)
-			<-restart // block until the watcher says something changed
-			continue  // retry the build before moving on to running
+			b.log().Error("watch", "name", b.Name, "error", err)
+
+			// block until the watcher says something changed
+			select {
+			case <-parentContext.Done():
+				b.log().Warn("shutdown signaled", "name", b.Name)
+				reap()
+				return
+			case change := <-restart:
+				detected, stopped = changeTime(change), 0
+				continue // retry the build before moving on to running
+			}
 		}
 
 		runContext, runCancel := context.WithCancel(parentContext)
-		go b.Run(runContext)
+		started := time.Now()     // when the run process last launched
+		backoff := autoRestartMin // delay before the next auto restart
+		var sigs chan os.Signal   // reload signals for the run process
 
-		select {
-		case <-parentContext.Done():
-			slog.Warn("shutdown signaled", "name", b.Name)
-			runCancel()
-			return
-		case <-restart:
-			slog.Warn("restart signal", "name", b.Name)
+		// launch starts the run process and its post-reload sequence, shared
+		// by the first launch, restarts and delayed relaunches
+		launch := func() {
+			b.setPhase(phaseRunning)
+			running++
+			started = time.Now()
+			sigs = make(chan os.Signal, 1)
+			b.Hooks.runStart(b.Name)
+			go func(ctx context.Context) { exited <- runExit{ctx, b.run(ctx, "", sigs)} }(runContext)
+			b.postReload(runContext, true)
+
+			// let a proxy following this group know when its first run is up
+			if b.ReadyCheck != "" && !b.groups.isReady(b.Name) {
+				go func(ctx context.Context) {
+					if b.waitReady(ctx, "") == nil {
+						b.groups.markReady(b.Name)
+					}
+				}(runContext)
+			}
+		}
+
+		// swapIn starts the next blue/green instance, which keeps the serving
+		// one alive until it is ready
+		swapIn := func() {
+			running++
+			started = time.Now()
+			swaps := b.swaps
+			serving = b.swap(runContext, runCancel, serving, exited)
+			b.setPhase(phaseRunning)
+			if b.swaps > swaps {
+				b.postReload(runContext, false)
+			}
+		}
+
+		// sidecars run alongside every instance of the run process
+		sideContext, sideCancel := context.WithCancel(parentContext)
+		var sidecars sync.WaitGroup
+		b.startSidecars(sideContext, &sidecars)
+
+		// stop ends the run process on restart
+		stop := func() { runCancel() }
+
+		// blue/green keeps the serving instance alive until the next is ready
+		if b.NoRun {
+			b.log().Info("build complete, waiting for changes", "name", b.Name)
+		} else if len(b.BlueGreenPorts) > 0 {
+			swapIn()
+			stop = func() {}
+		} else {
+			launch()
+		}
+
+		// report how long the loop from change to running process took
+		if !detected.IsZero() {
+			b.log().Info("reload complete", "name", b.Name, "total", time.Since(detected), "stop", stopped, "build", built)
+			detected = time.Time{}
+		}
+
+		var relaunch <-chan time.Time // fires when the run process is due again
+		var delay string              // the delay state relaunch is waiting out
+
+		// restartRun replaces the run process without a build, sidecars are
+		// left running
+		restartRun := func() {
+			relaunch = nil
+			if b.NoRun {
+				return
+			}
+			if len(b.BlueGreenPorts) > 0 {
+				runContext, runCancel = context.WithCancel(parentContext)
+				swapIn()
+				return
+			}
 			runCancel()
-			continue
+			reap()
+			runContext, runCancel = context.WithCancel(parentContext)
+			launch()
+		}
+
+	wait:
+		for {
+			select {
+			case <-parentContext.Done():
+				b.log().Warn("shutdown signaled", "name", b.Name)
+				runCancel()
+				sideCancel()
+				// let every run process finish its graceful shutdown
+				reap()
+				sidecars.Wait()
+				return
+			case exit := <-exited:
+				running--
+
+				// a cancelled context means we stopped it, anything else is a
+				// crash or a one-shot finishing
+				if exit.ctx.Err() != nil {
+					continue
+				}
+				err := exit.err
+
+				if !b.OneShot {
+					if b.AutoRestart {
+						// a process that stayed up a while earns a fresh backoff
+						if time.Since(started) > autoRestartStable {
+							backoff = autoRestartMin
+						}
+						b.log().Warn("restart delayed", b.delayed("backoff", backoff, "run exited unexpectedly", "error", err)...)
+						relaunch, delay = time.After(backoff), "backoff"
+						backoff = min(backoff*2, autoRestartMax)
+					}
+					continue
+				}
+
+				// a failed one-shot blocks like a failed build until a change
+				if err != nil {
+					b.log().Error("one-shot failed", "name", b.Name, "error", err)
+					continue
+				}
+
+				b.log().Info("one-shot complete", "name", b.Name)
+				if b.OneShotInterval > 0 {
+					b.log().Info("restart delayed", b.delayed("scheduled", time.Duration(b.OneShotInterval), "oneShotInterval")...)
+					relaunch, delay = time.After(time.Duration(b.OneShotInterval)), "scheduled"
+				}
+			case <-relaunch:
+				b.log().Info("restart resumed", b.resumed(delay, "run relaunch")...)
+				restartRun()
+			case change := <-restart:
+
+				// a lighter step covers this change, skip the full build
+				if step := change.Step; step != nil {
+					if step.BuildCmd != "" || step.ReloadMode == "" {
+						b.runStep(parentContext, step)
+						b.markBuilt()
+					}
+
+					switch step.ReloadMode {
+					case "":
+						continue // the run process keeps going
+					case "signal":
+						if b.signalRun(sigs, running > 0) {
+							continue
+						}
+					case "restart":
+						b.Hooks.reload(b.Name, change)
+						restartRun()
+						b.log().Info("reload complete", "name", b.Name, "step", step.Name, "total", time.Since(changeTime(change)))
+						continue
+					}
+				} else if !change.Manual && b.ReloadMode == "signal" && b.signalRun(sigs, running > 0) {
+					continue
+				}
+
+				b.log().Warn("restart signal", "name", b.Name, "manual", change.Manual, "files", len(change.Added)+len(change.Removed)+len(change.Modified))
+
+				// pause the group rather than rebuild in a tight loop
+				if !change.Manual && !guard.allow(b.MaxRestartsPerMinute) {
+					if !b.cooldown(parentContext, restart) {
+						b.log().Warn("shutdown signaled", "name", b.Name)
+						runCancel()
+						sideCancel()
+						reap()
+						sidecars.Wait()
+						return
+					}
+					guard.reset()
+				}
+
+				b.Hooks.reload(b.Name, change)
+				detected = changeTime(change)
+				b.setPhase(phaseRestarting)
+				stop()
+				sideCancel()
+
+				// let the old process exit so it releases its port, and on
+				// windows its executable, before we rebuild
+				stopping := time.Now()
+				if len(b.BlueGreenPorts) == 0 {
+					reap()
+				}
+				sidecars.Wait()
+				stopped = time.Since(stopping)
+				break wait
+			}
 		}
 	}
 }
 
+// changeTime returns when change was detected, now for a manual reload
+func changeTime(change Change) time.Time {
+	if change.Detected.IsZero() {
+		return time.Now()
+	}
+	return change.Detected
+}
+
 // Watch starts a ticker and compares scans for changes in the files.
 //
 // Calling cancel on the parent context will stop the watch process otherwise
 // it ticks ever duration to check for changes. If a change is detected it
-// signals the restart channel.
+// signals the restart channel with the globs that changed. An unset or invalid
+// heartBeat falls back to FallbackHeartBeat.
 //
-// ex: b.Watch(ctx)
-func (b *Build) Watch(parentContext context.Context, restart chan struct{}) {
+// ex: b.Watch(ctx, restart)
+func (b *Build) Watch(parentContext context.Context, restart chan Change) {
 
-	tick := time.NewTicker(b.HeartBeat)
+	// time.NewTicker panics on a non-positive duration, which would take down
+	// every build group, so fall back to FallbackHeartBeat instead
+	if b.HeartBeat <= 0 {
+		b.log().Warn("heartBeat invalid or not defined", "name", b.Name, "heartBeat", b.HeartBeat, "fallback", FallbackHeartBeat)
+		b.HeartBeat = FallbackHeartBeat
+	}
+
+	tick := time.NewTicker(b.nextBeat())
 	defer tick.Stop()
 
-	memoized := MatchFiles(b.Match)
+	// directory listings are cached between ticks to keep idle scans cheap
+	cache := &dirCache{log: b.log()}
+	// repeated warnings are only logged once until the state changes
+	quiet := &quietLog{log: b.log()}
+	memoized := b.scan(cache, quiet)
+	var goneSince time.Time // when watched directories went missing
+
+	// an existing trigger file isn't a touch, only later changes are
+	var triggerMod time.Time
+	b.triggered(&triggerMod)
 
 	for {
 
 		select {
 		case <-parentContext.Done():
-			slog.Error("watch parent interrupt", "name", b.Name)
+			b.log().Error("watch parent interrupt", "name", b.Name)
 			return
 		case <-tick.C:
+			if b.HeartBeatJitter > 0 {
+				tick.Reset(b.nextBeat())
+			}
 
 			start := time.Now()
-			files := MatchFiles(b.Match)
+			files := b.scan(cache, quiet)
+
+			// a touched trigger file reloads whatever the globs say
+			if b.triggered(&triggerMod) {
+				b.log().Info("watch trigger file touched", "name", b.Name, "triggerFile", b.TriggerFile)
+				memoized = files
+				select {
+				case restart <- Change{Manual: true, Detected: start}:
+				case <-parentContext.Done():
+					return
+				}
+				continue
+			}
 
 			// if no files are found, skip the check
-			if len(files) == 0 {
-				slog.Warn("watch no matches found", "name", b.Name)
+			if countFiles(files) == 0 {
+				quiet.warn("no matches", "watch no matches found", "name", b.Name)
 				continue
 			}
 
-			// if no files to compare against, skip the check
-			if len(memoized) == 0 {
-				slog.Warn("watch no matches found", "name", b.Name)
+			if quiet.reset("no matches") {
+				b.log().Info("watch matches found", "name", b.Name, "files", countFiles(files))
+			}
+
+			// collect the globs and paths that differ from the last scan
+			change := Change{Detected: start}
+			for i, glob := range b.patterns() {
+				added, removed, modified := b.diffFiles(memoized[i], files[i])
+				if len(added)+len(removed)+len(modified) == 0 {
+					continue
+				}
+				change.Globs = append(change.Globs, glob)
+				change.Added = append(change.Added, added...)
+				change.Removed = append(change.Removed, removed...)
+				change.Modified = append(change.Modified, modified...)
+			}
+
+			if len(change.Globs) == 0 {
+				if quiet.reset("dir gone") {
+					b.log().Info("watch directories back, no change", "name", b.Name)
+				}
+				goneSince = time.Time{}
 				continue
 			}
 
-			if len(files) != len(memoized) {
-				slog.Debug("watch change detected", "name", b.Name, "duration", time.Since(start))
-				restart <- struct{}{}
+			// churn left by the build that just finished isn't a change
+			if b.settling(start) {
+				b.log().Debug("watch change while settling after a build, not restarting", "name", b.Name, "globs", change.Globs, "added", change.Added, "removed", change.Removed, "modified", change.Modified)
 				memoized = files
 				continue
 			}
 
-			for i, file := range files {
-				if file.ModTime() != memoized[i].ModTime() {
-					slog.Debug("watch change detected", "name", b.Name, "duration", time.Since(start))
-					restart <- struct{}{}
-					memoized = files
+			// tools like git checkout briefly remove whole trees, hold off on
+			// restarting until the directories come back or stay gone
+			if gone := missingDirs(change.Removed); len(gone) > 0 {
+				if goneSince.IsZero() {
+					goneSince = start
+				}
+				if start.Sub(goneSince) < b.dirGoneGrace() {
+					quiet.warn("dir gone", "restart delayed", b.delayed("waiting", b.dirGoneGrace()-start.Sub(goneSince), "watched directories missing", "dirs", gone)...)
 					continue
 				}
+				b.log().Warn("restart resumed", append(b.resumed("waiting", "watched directories still missing"), "dirs", gone)...)
+			}
+			quiet.reset("dir gone")
+			goneSince = time.Time{}
+
+			// let Start know whether a step covers the change
+			change.Step = b.stepFor(change)
+
+			b.log().Debug("watch change detected", "name", b.Name, "globs", change.Globs, "added", change.Added, "removed", change.Removed, "modified", change.Modified, "duration", time.Since(start))
+			memoized = files
+
+			// don't block forever if Start has already stopped
+			select {
+			case restart <- change:
+			case <-parentContext.Done():
+				return
 			}
 		}
 	}
 }
 
-// MatchFiles is a function that takes a list of globs and returns array of FileInfo
-//
-//	ex: files := MatchFiles([]string{"test/*.go", "test/wwwroot/*"})
-func MatchFiles(globs []string) []fs.FileInfo {
-	files := []fs.FileInfo{}
+// scan matches each plain glob in Match and each of the Extensions separately so a
+// change can be traced back to the pattern that matched it
+func (b *Build) scan(cache *dirCache, quiet *quietLog) []map[string]fs.FileInfo {
 
-	for _, glob := range globs {
-		matches, err := filepath.Glob(glob)
-		if err != nil {
-			slog.Error("watch", "error", err)
+	// negations are applied through ignored below
+	scans := make([]map[string]fs.FileInfo, 0, len(b.Match)+len(b.Extensions))
+	for _, glob := range includes(b.Match) {
+
+		// a bad pattern would otherwise be reported every heartbeat
+		if _, err := filepath.Match(glob, ""); err != nil {
+			quiet.warn("bad pattern "+glob, "watch bad pattern", "name", b.Name, "glob", glob, "error", err)
+			scans = append(scans, nil)
 			continue
 		}
 
-		for _, match := range matches {
-			slog.Debug("watch", "match", match)
+		// only the directories are stat'd, the file names come from listings
+		if b.WatchDirsOnly {
+			paths, err := cache.glob(glob)
+			if err != nil {
+				quiet.warn("glob "+glob, "watch", "name", b.Name, "glob", glob, "error", err)
+			}
+			paths = slices.DeleteFunc(paths, func(path string) bool { return b.excluded(path) || b.ignored(path) })
+			scans = append(scans, dirsOf(paths, b.log()))
+			continue
+		}
 
-			file, err := os.Stat(match)
+		// read whole directories in batches rather than stat every file
+		if b.NetworkPoll != nil {
+			paths, err := cache.glob(glob)
 			if err != nil {
-				slog.Error("watch", "error", err)
-				continue
+				quiet.warn("glob "+glob, "watch", "name", b.Name, "glob", glob, "error", err)
 			}
+			scans = append(scans, b.NetworkPoll.stat(paths, b.log()))
+			continue
+		}
 
-			files = append(files, file)
+		scans = append(scans, matchFileMap([]string{glob}, b.ScanWorkers, cache))
+	}
+
+	for _, ext := range b.Extensions {
+		files := b.matchExtension(ext)
+		if b.WatchDirsOnly {
+			paths := slices.Collect(maps.Keys(files))
+			paths = slices.DeleteFunc(paths, func(path string) bool { return b.excluded(path) || b.ignored(path) })
+			files = dirsOf(paths, b.log())
 		}
+		scans = append(scans, files)
+	}
 
+	// keep the build's own output and ignored paths from triggering a restart
+	for _, files := range scans {
+		for path, info := range files {
+			// globs can match directories, which only .glrignore tells apart
+			if b.excluded(path) || b.ignored(path) || (info != nil && info.IsDir() && b.groups.ignoredFile(path, true)) {
+				delete(files, path)
+			}
+		}
+	}
+
+	return scans
+}
+
+// Matches returns the sorted paths Watch currently sees for the group, after
+// Ignore and the build output are left out. With WatchDirsOnly these are the
+// watched directories.
+//
+//	ex: for _, path := range b.Matches() { fmt.Println(path) }
+func (b *Build) Matches() []string {
+
+	paths := []string{}
+	for _, files := range b.scan(nil, &quietLog{log: b.log()}) {
+		for path := range files {
+			paths = append(paths, path)
+		}
+	}
+
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
+
+// dirGoneGrace is how long Watch waits on missing directories to return,
+// at least a few heartbeats
+func (b *Build) dirGoneGrace() time.Duration {
+	return max(dirGoneMin, 3*time.Duration(b.HeartBeat))
+}
+
+// maxJitter caps HeartBeatJitter so a heartbeat never drops to zero
+const maxJitter = 0.5
+
+// nextBeat returns HeartBeat spread by a random amount within HeartBeatJitter
+func (b *Build) nextBeat() time.Duration {
+
+	beat := time.Duration(b.HeartBeat)
+	if b.HeartBeatJitter <= 0 {
+		return beat
 	}
 
-	return files
+	spread := float64(beat) * min(b.HeartBeatJitter, maxJitter)
+	return beat + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// dirGoneMin is the shortest wait on missing directories
+const dirGoneMin = 3 * time.Second
+
+// missingDirs returns the sorted parent directories of removed paths that no
+// longer exist
+func missingDirs(removed []string) []string {
+
+	gone := []string{}
+	for _, path := range removed {
+		dir := filepath.Dir(path)
+		if slices.Contains(gone, dir) {
+			continue
+		}
+		if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+			gone = append(gone, dir)
+		}
+	}
+
+	slices.Sort(gone)
+	return gone
+}
+
+// countFiles returns the total number of files across scans
+func countFiles(scans []map[string]fs.FileInfo) int {
+
+	count := 0
+	for _, files := range scans {
+		count += len(files)
+	}
+
+	return count
+}
+
+// diffFiles compares two scans by path returning the sorted paths that were
+// added, removed or modified, as decided by Compare
+func (b *Build) diffFiles(memoized, files map[string]fs.FileInfo) (added, removed, modified []string) {
+
+	for path, file := range files {
+		previous, ok := memoized[path]
+		switch {
+		case !ok:
+			added = append(added, path)
+		case b.modified(previous, file):
+			modified = append(modified, path)
+		}
+	}
+
+	for path := range memoized {
+		if _, ok := files[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+
+	slices.Sort(added)
+	slices.Sort(removed)
+	slices.Sort(modified)
+
+	return added, removed, modified
+}
+
+// quietLog suppresses repeats of a warning until it is reset, keeping the
+// watch loop from flooding the console every heartbeat
+type quietLog struct {
+	log    *slog.Logger
+	logged map[string]bool
+}
+
+// warn logs msg unless a warning with the same key was already logged and
+// not yet reset
+func (q *quietLog) warn(key, msg string, args ...any) {
+
+	if q.logged == nil {
+		q.logged = make(map[string]bool)
+	}
+
+	if q.logged[key] {
+		return
+	}
+
+	q.logged[key] = true
+	q.log.Warn(msg, args...)
+}
+
+// reset allows key to be logged again, reporting whether it had been logged
+func (q *quietLog) reset(key string) bool {
+
+	logged := q.logged[key]
+	delete(q.logged, key)
+
+	return logged
 }
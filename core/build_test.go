@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess stands in for build and run commands. With
+// GLR_HELPER_OUTPUT set it writes the output and fails, with
+// GLR_HELPER_LISTEN set it serves TCP, writing its address to that file, until
+// killed. Otherwise it exits cleanly.
+func TestHelperProcess(t *testing.T) {
+
+	if output, ok := os.LookupEnv("GLR_HELPER_OUTPUT"); ok {
+		fmt.Fprint(os.Stderr, output)
+		os.Exit(1)
+	}
+
+	if path, ok := os.LookupEnv("GLR_HELPER_LISTEN"); ok {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			os.Exit(2)
+		}
+		os.WriteFile(path, []byte(listener.Addr().String()), 0o644)
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				os.Exit(3)
+			}
+			conn.Close()
+		}
+	}
+}
+
+// helperCommand returns the command and arguments running TestHelperProcess
+func helperCommand() (string, []string) {
+	return os.Args[0], []string{"-test.run=^TestHelperProcess$"}
+}
+
+func TestBuildTimeoutLeavesRunProcess(t *testing.T) {
+
+	addrFile := filepath.Join(t.TempDir(), "addr")
+	cmd, args := helperCommand()
+
+	b := &Build{
+		Name:         "timeout",
+		BuildCmd:     cmd,
+		BuildArgs:    args,
+		BuildEnv:     []string{"GORACE=atexit_sleep_ms=0"}, // -race waits 1s on exit
+		BuildTimeout: Duration(time.Second),
+		RunCmd:       cmd,
+		RunArgs:      args,
+		RunEnv:       []string{"GLR_HELPER_LISTEN=" + addrFile},
+		HeartBeat:    Duration(50 * time.Millisecond),
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.Start(ctx, make(chan Change))
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// wait for the run process to start listening
+	var addr []byte
+	for deadline := time.Now().Add(10 * time.Second); len(addr) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("run process never started")
+		}
+		time.Sleep(20 * time.Millisecond)
+		addr, _ = os.ReadFile(addrFile)
+	}
+
+	// well past the build timeout the server must still answer
+	time.Sleep(time.Until(start.Add(2 * time.Duration(b.BuildTimeout))))
+
+	conn, err := net.DialTimeout("tcp", string(addr), time.Second)
+	if err != nil {
+		t.Fatalf("run process gone after the build timeout: %v", err)
+	}
+	conn.Close()
+}
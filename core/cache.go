@@ -0,0 +1,56 @@
+package core
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// A reload that takes a second one time and twenty the next is usually the
+// go build cache at work. With ReportCache set, go build and go install get
+// -v, which lists each package actually compiled, and "build success" logs
+// how many were rebuilt. Zero means everything came from the cache.
+
+// reportsCache reports whether the build is a go build or go install that
+// ReportCache applies to
+func (b *Build) reportsCache(args []string) bool {
+
+	if !b.ReportCache {
+		return false
+	}
+
+	name := strings.TrimSuffix(filepath.Base(b.BuildCmd), ".exe")
+	if name != "go" || len(args) == 0 || (args[0] != "build" && args[0] != "install") {
+		b.log().Warn("reportCache only applies to go build and go install", "name", b.Name, "buildCmd", b.BuildCmd)
+		return false
+	}
+
+	return true
+}
+
+// withVerbose adds -v after the go subcommand unless it is already there
+func withVerbose(args []string) []string {
+	if slices.Contains(args, "-v") {
+		return args
+	}
+	return slices.Insert(slices.Clone(args), 1, "-v")
+}
+
+// splitVerbose separates the packages go build -v listed in output from
+// everything else, like the "# pkg" headers and messages of warnings
+func splitVerbose(output []byte) ([]string, []byte) {
+
+	packages := []string{}
+	var rest bytes.Buffer
+	for line := range bytes.Lines(output) {
+		text := strings.TrimRight(string(line), "\r\n")
+		if text == "" || strings.HasPrefix(text, "#") || strings.ContainsAny(text, " \t:") {
+			rest.Write(line)
+			continue
+		}
+		packages = append(packages, text)
+	}
+
+	return packages, rest.Bytes()
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// Editors and filesystems disagree on what a save touches. Some keep the
+// modtime on a rewrite, some bump it on a no-op save, and a chmod +x is
+// invisible to modtime alone. Compare picks which attributes count.
+
+// compareAttrs are the file attributes Compare accepts
+var compareAttrs = []string{"modtime", "size", "mode"}
+
+// modified reports whether any compared attribute differs between previous
+// and file
+func (b *Build) modified(previous, file fs.FileInfo) bool {
+
+	compare := b.Compare
+	if len(compare) == 0 {
+		compare = []string{"modtime"}
+	}
+
+	for _, attr := range compare {
+		switch attr {
+		case "modtime":
+			if !file.ModTime().Equal(previous.ModTime()) {
+				return true
+			}
+		case "size":
+			if file.Size() != previous.Size() {
+				return true
+			}
+		case "mode":
+			if file.Mode() != previous.Mode() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkCompare rejects attributes Compare doesn't know, a typo would
+// otherwise quietly stop changes from being seen
+func (b *Build) checkCompare() error {
+	for _, attr := range b.Compare {
+		if !slices.Contains(compareAttrs, attr) {
+			return fmt.Errorf("build group %q: unknown compare attribute %q, expected one of %v", b.Name, attr, compareAttrs)
+		}
+	}
+	return nil
+}
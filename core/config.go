@@ -1,9 +1,15 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -15,6 +21,16 @@ type Config struct {
 	// Builds is a list of Build structs
 	Builds []Build `json:"builds"`
 
+	// BuildDefaults fills in every build group, anything a group sets
+	// itself wins, see defaults.go
+	//	ex: {"buildCmd": "go", "buildArgs": ["build", "-o", "build/"]}
+	BuildDefaults *Build `json:"buildDefaults,omitzero"`
+
+	// DefaultHeartBeat is used by every build group that doesn't set its own
+	// heartBeat, before falling back to FallbackHeartBeat
+	//	ex: "500ms"
+	DefaultHeartBeat Duration `json:"defaultHeartBeat,omitzero"`
+
 	// ReverseProxy is a map of paths to HttpTarget
 	//	ex: "/api" -> HttpTarget{Host: "http://localhost:8080"}
 	ReverseProxy map[string]HttpTarget `json:"reverseProxy"`
@@ -26,6 +42,49 @@ type Config struct {
 	TLSCertFile string `json:"tlsCertFile,omitzero"`
 	// TLSKeyFile is the relative path to the TLS key file for the server
 	TLSKeyFile string `json:"tlsKeyFile,omitzero"`
+
+	// H2C lets the reverse proxy serve plaintext HTTP/2 to clients that speak
+	// it with prior knowledge, TLS already negotiates HTTP/2
+	H2C bool `json:"h2c,omitzero"`
+
+	// LiveReload serves a client script from the reverse proxy that reloads
+	// the page after each reload and overlays build errors, see livereload.go
+	LiveReload bool `json:"liveReload,omitzero"`
+
+	// BasicAuth requires credentials for every request to the reverse proxy
+	// when set, see auth.go
+	BasicAuth *BasicAuth `json:"basicAuth,omitzero"`
+
+	// ShutdownOrder names build groups to stop first on shutdown, in order,
+	// the rest follow in reverse config order
+	//	ex: ["frontend", "backend"]
+	ShutdownOrder []string `json:"shutdownOrder,omitzero"`
+
+	// SharedMatch watches globs shared by several build groups, restarting
+	// every group named when they change, see shared.go
+	SharedMatch []SharedMatch `json:"sharedMatch,omitzero"`
+
+	// Profiles are named variations merged over this config with --profile,
+	// see profile.go
+	//	ex: {"test": {"builds": [{"name": "api", "oneShot": true}]}}
+	Profiles map[string]json.RawMessage `json:"profiles,omitzero"`
+
+	// StaticServer optionally serves a directory of static files
+	StaticServer *StaticServer `json:"staticServer,omitzero"`
+
+	// Logger receives the reverse proxy and static server log output,
+	// defaulting to slog.Default() when nil
+	Logger *slog.Logger `json:"-"`
+
+	groups *groupState // shared with the build groups, see groupstate.go
+}
+
+// log returns the config's Logger or slog.Default()
+func (c *Config) log() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
 }
 
 // NewConfig returns a new Config with reasonable defaults
@@ -44,7 +103,7 @@ func NewConfig() *Config {
 				Name:        "webserver",
 				Description: "sample webserver",
 				Match:       []string{"*.go"},
-				HeartBeat:   time.Duration(1 * time.Second),
+				HeartBeat:   Duration(1 * time.Second),
 				BuildCmd:    "go",
 
 				/*
@@ -75,9 +134,10 @@ func NewConfig() *Config {
 				*/
 
 				RunCmd:  "./webserver",
-				RunArgs: []string{"--www-bind", ":8081", "--www-root", "wwwroot"},
-				RunEnv:  []string{"WWWBIND=8081", "WWWROOT=wwwroot"},
+				RunArgs: []string{"--www-bind", ":{port}", "--www-root", "wwwroot"},
+				RunEnv:  []string{"WWWBIND={port}", "WWWROOT=wwwroot"},
 				RunDir:  "build",
+				Port:    "8081",
 			},
 		},
 	}
@@ -112,14 +172,47 @@ func (c *Config) Save(filename string) error {
 		return err
 	}
 
-	err = os.WriteFile(filename, data, 0644)
+	// keep the permissions of any existing config
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	// write to a temp file beside the config and rename it into place so a
+	// failed write never leaves a truncated config behind
+	temp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
 	if err != nil {
 		return err
 	}
-	return nil
+	defer os.Remove(temp.Name()) // no-op once renamed
+
+	_, err = temp.Write(data)
+	if err != nil {
+		temp.Close()
+		return err
+	}
+
+	err = temp.Sync()
+	if err != nil {
+		temp.Close()
+		return err
+	}
+
+	err = temp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(temp.Name(), mode)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), filename)
 }
 
-// Load reads filename into a Config struct
+// Load reads filename into a Config struct. Files ending in .jsonc may
+// contain comments and trailing commas, .json files must be strict JSON.
 //
 //	ex: myConfig.Load("go-live-reload.json")
 func (c *Config) Load(filename string) error {
@@ -132,9 +225,145 @@ func (c *Config) Load(filename string) error {
 		return err
 	}
 
+	if strings.EqualFold(filepath.Ext(filename), ".jsonc") {
+		data = stripJSONC(data)
+	}
+
 	err = json.Unmarshal(data, c)
 	if err != nil {
 		return err
 	}
+
+	err = c.applyBuildDefaults(data)
+	if err != nil {
+		return err
+	}
+
+	err = checkNames(c.Builds)
+	if err != nil {
+		return err
+	}
+
+	err = c.checkAuth()
+	if err != nil {
+		return err
+	}
+
+	err = c.expandPaths()
+	if err != nil {
+		return err
+	}
+
+	c.applyDefaults()
+	c.attach()
+
+	return c.ResolveToolchains()
+}
+
+// WatchConfig polls filename every heartBeat and sends a freshly loaded Config
+// on changed whenever the file's modtime changes. A config that fails to load
+// is logged to log, or slog.Default() when nil, and skipped. The watch stops
+// when ctx is cancelled.
+//
+//	ex: go WatchConfig(ctx, "go-live-reload.json", time.Second, changed, logger)
+func WatchConfig(ctx context.Context, filename string, heartBeat time.Duration, changed chan<- *Config, log *slog.Logger) {
+
+	if log == nil {
+		log = slog.Default()
+	}
+
+	filename = filepath.FromSlash(filename)
+
+	var modTime time.Time
+	if info, err := os.Stat(filename); err == nil {
+		modTime = info.ModTime()
+	}
+
+	tick := time.NewTicker(heartBeat)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+
+			info, err := os.Stat(filename)
+			if err != nil || info.ModTime().Equal(modTime) {
+				continue
+			}
+			modTime = info.ModTime()
+
+			c := &Config{}
+			err = c.Load(filename)
+			if err != nil {
+				log.Error("watch-config", "config", filename, "error", err)
+				continue
+			}
+
+			log.Info("watch-config change detected", "config", filename)
+
+			// don't block forever once nothing reads changes at shutdown
+			select {
+			case changed <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// applyDefaults gives DefaultHeartBeat to every build group without its own
+// heartBeat, remembering which ones so a profile can change it again
+func (c *Config) applyDefaults() {
+
+	if c.DefaultHeartBeat <= 0 {
+		return
+	}
+
+	for i := range c.Builds {
+		if c.Builds[i].HeartBeat == 0 {
+			c.Builds[i].HeartBeat = c.DefaultHeartBeat
+			c.Builds[i].defaultBeat = true
+		}
+	}
+}
+
+// checkAuth rejects a basicAuth block on the reverse proxy or static server
+// that would let anyone in, like one without a password
+func (c *Config) checkAuth() error {
+
+	err := c.BasicAuth.check()
+	if err != nil {
+		return err
+	}
+
+	if c.StaticServer != nil {
+		err = c.StaticServer.BasicAuth.check()
+		if err != nil {
+			return fmt.Errorf("staticServer %w", err)
+		}
+	}
+
 	return nil
 }
+
+// checkNames rejects build groups sharing a name, which would make
+// --build-groups, the logs and the control socket ambiguous. Every clash is
+// reported at once so they can all be fixed in one edit.
+//
+//	ex: err := checkNames(config.Builds)
+func checkNames(builds []Build) error {
+
+	var errs []error
+	seen := make(map[string]int)
+	for i, build := range builds {
+		if first, ok := seen[build.Name]; ok {
+			errs = append(errs, fmt.Errorf("builds[%d].name and builds[%d].name are both %q, build group names must be unique", first, i, build.Name))
+			continue
+		}
+		seen[build.Name] = i
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckNamesReportsEveryDuplicate(t *testing.T) {
+
+	builds := []Build{{Name: "api"}, {Name: "web"}, {Name: "api"}, {Name: "worker"}, {Name: "web"}, {Name: "api"}}
+
+	err := checkNames(builds)
+	if err == nil {
+		t.Fatal("checkNames accepted duplicate names")
+	}
+
+	for _, want := range []string{
+		`builds[0].name and builds[2].name are both "api"`,
+		`builds[1].name and builds[4].name are both "web"`,
+		`builds[0].name and builds[5].name are both "api"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error is missing %q:\n%s", want, err)
+		}
+	}
+
+	if err := checkNames(builds[:2]); err != nil {
+		t.Errorf("checkNames(unique) = %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Container runs a build group's build and run commands inside a container
+// instead of on the host. The working directory is mounted into the
+// container at Workdir and BuildDir and RunDir are resolved beneath it.
+//
+//	ex: {"image": "golang:1.24", "ports": ["8081:8081"], "mounts": ["gomod:/go/pkg/mod"]}
+type Container struct {
+
+	// Runtime is the container CLI, docker or podman, default docker
+	Runtime string `json:"runtime,omitzero"`
+
+	// Image the commands run in
+	// ex: "golang:1.24"
+	Image string `json:"image"`
+
+	// Workdir is where the working directory is mounted, default /src
+	Workdir string `json:"workdir,omitzero"`
+
+	// Mounts are extra host:container[:options] volumes, host paths starting
+	// with . are resolved against the working directory
+	// ex: ["./.cache/go:/root/.cache/go-build", "gomod:/go/pkg/mod"]
+	Mounts []string `json:"mounts,omitzero"`
+
+	// Ports are published for the run command only, {port} is replaced with
+	// the blue/green port
+	// ex: ["8081:8081"]
+	Ports []string `json:"ports,omitzero"`
+
+	// Args are passed to the runtime before the image
+	// ex: ["--network", "host"]
+	Args []string `json:"args,omitzero"`
+}
+
+// command wraps name and args in a container run, returning the runtime and
+// its arguments. The env values are passed with -e so the host environment
+// doesn't leak into the container.
+//
+//	ex: name, args, err := c.command("go", []string{"build"}, "cmd/api", env, nil, false)
+func (c *Container) command(name string, args []string, dir string, env, ports []string, stdin bool) (string, []string, error) {
+
+	if c.Image == "" {
+		return "", nil, errors.New("container image not defined")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", nil, err
+	}
+
+	runtime := c.Runtime
+	if runtime == "" {
+		runtime = "docker"
+	}
+
+	workdir := c.Workdir
+	if workdir == "" {
+		workdir = "/src"
+	}
+
+	// --init forwards the interrupt we send on restart to the command
+	run := []string{"run", "--rm", "--init",
+		"-v", wd + ":" + workdir,
+		"-w", path.Join(workdir, filepath.ToSlash(dir)),
+	}
+
+	if stdin {
+		run = append(run, "-i")
+	}
+
+	for _, mount := range c.Mounts {
+		// docker reads anything else as a named volume or absolute path
+		if strings.HasPrefix(mount, ".") {
+			host, target, _ := strings.Cut(mount, ":")
+			mount = filepath.Join(wd, filepath.FromSlash(host)) + ":" + target
+		}
+		run = append(run, "-v", mount)
+	}
+
+	for _, port := range ports {
+		run = append(run, "-p", port)
+	}
+
+	for _, kv := range env {
+		run = append(run, "-e", kv)
+	}
+
+	run = slices.Concat(run, c.Args, []string{c.Image, name}, args)
+
+	return runtime, run, nil
+}
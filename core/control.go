@@ -0,0 +1,137 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+// The control socket accepts one line command per connection, writes the
+// response and closes the connection.
+//
+//	reload [group...]  rebuild and restart groups, all if none are named
+//	stop group...      stop groups and their run processes
+//	start group...     start stopped groups
+//	status             list every group and whether it is running
+
+// ServeControl listens on a unix socket at path and dispatches commands to
+// the supervisor until ctx is cancelled
+//
+//	ex: go s.ServeControl(ctx, "go-live-reload.sock")
+func (s *Supervisor) ServeControl(ctx context.Context, path string) {
+
+	// clear out a stale socket left behind by a previous run
+	if info, err := os.Lstat(path); err == nil && info.Mode().Type() == fs.ModeSocket {
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		s.log().Error("control-socket", "error", err)
+		return
+	}
+
+	// closing the listener also removes the socket file
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	s.log().Info("control-socket listen", "path", path)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.log().Error("control-socket", "error", err)
+			}
+			return
+		}
+
+		go s.handleControl(conn)
+	}
+}
+
+// handleControl reads a single command from conn and writes the response
+func (s *Supervisor) handleControl(conn net.Conn) {
+
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.log().Error("control-socket", "error", err)
+		return
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		fmt.Fprintln(conn, "error: empty command")
+		return
+	}
+
+	s.log().Info("control-socket", "command", fields)
+
+	command, names := fields[0], fields[1:]
+
+	switch command {
+	case "reload":
+		err = s.Reload(names...)
+	case "stop":
+		if len(names) == 0 {
+			err = errors.New("stop needs at least one build group")
+			break
+		}
+		err = s.Stop(names...)
+	case "start":
+		if len(names) == 0 {
+			err = errors.New("start needs at least one build group")
+			break
+		}
+		err = s.Start(names...)
+	case "status":
+		for _, status := range s.Status() {
+			state := "stopped"
+			if status.Running {
+				state = "running"
+			}
+			fmt.Fprintf(conn, "%s\t%s\n", status.Name, state)
+		}
+		return
+	default:
+		err = fmt.Errorf("unknown command %q", command)
+	}
+
+	if err != nil {
+		fmt.Fprintln(conn, "error:", err)
+		return
+	}
+
+	fmt.Fprintln(conn, "ok")
+}
+
+// Control sends a single command to the control socket at path and copies
+// the response to w
+//
+//	ex: err := Control("go-live-reload.sock", []string{"reload", "frontend"}, os.Stdout)
+func Control(path string, args []string, w io.Writer) error {
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = fmt.Fprintln(conn, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, conn)
+	return err
+}
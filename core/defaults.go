@@ -0,0 +1,65 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// BuildDefaults is written like a build group and fills in every group of
+// the config, so services sharing a build command only differ in what's
+// theirs like the name and watchDir. Whatever a group writes itself wins,
+// even an empty list or false, and objects like container merge field by
+// field while lists and strings replace.
+//
+//	ex: {"buildDefaults": {"buildCmd": "go", "buildArgs": ["build", "-o", "build/"]}, "builds": [{"name": "api", "watchDir": "api"}]}
+
+// applyBuildDefaults decodes every build in data over BuildDefaults
+func (c *Config) applyBuildDefaults(data []byte) error {
+
+	if c.BuildDefaults == nil {
+		return nil
+	}
+
+	if c.BuildDefaults.Name != "" {
+		return errors.New("buildDefaults can't set a name, build group names must be unique")
+	}
+
+	var raw struct {
+		Builds []json.RawMessage `json:"builds"`
+	}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	for i, build := range raw.Builds {
+		c.Builds[i], err = c.newBuild(build)
+		if err != nil {
+			return fmt.Errorf("builds[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// newBuild decodes raw over a copy of BuildDefaults, if any
+func (c *Config) newBuild(raw json.RawMessage) (Build, error) {
+
+	var build Build
+
+	if c.BuildDefaults != nil {
+		// a round trip gives each build its own copies of lists and objects
+		defaults, err := json.Marshal(c.BuildDefaults)
+		if err != nil {
+			return build, err
+		}
+		err = json.Unmarshal(defaults, &build)
+		if err != nil {
+			return build, err
+		}
+	}
+
+	err := json.Unmarshal(raw, &build)
+	return build, err
+}
@@ -0,0 +1,28 @@
+package core
+
+import "time"
+
+// Several features hold a restart back, and when nothing happens right after
+// a save it should be clear which one. Each logs a "restart delayed" line in
+// the same shape through delayed, naming its state and how long is left:
+//
+//	backoff    a crashed run process is relaunched after a growing pause
+//	throttled  too many restarts within a minute paused the group
+//	waiting    watched directories went missing and may come back
+//	scheduled  a finished one-shot reruns after oneShotInterval
+//
+// The matching "restart resumed" line names the state that ended.
+
+// delayed returns the log attributes for a restart delayed by state for left
+//
+//	ex: b.log().Warn("restart delayed", b.delayed("backoff", backoff, "run exited unexpectedly", "error", err)...)
+func (b *Build) delayed(state string, left time.Duration, reason string, args ...any) []any {
+	return append([]any{"name", b.Name, "state", state, "left", left, "reason", reason}, args...)
+}
+
+// resumed returns the log attributes for the end of a delay
+//
+//	ex: b.log().Info("restart resumed", b.resumed("throttled", "cooldown over")...)
+func (b *Build) resumed(state, reason string) []any {
+	return []any{"name", b.Name, "state", state, "reason", reason}
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// A failed build's compiler output scrolls by plainly in a busy console. With
+// a build group's ParseErrors naming one of the Parsers, the build output is
+// captured and each error is logged on its own at error level with a count,
+// the raw output is kept at debug. A successful build's output is passed
+// through untouched.
+
+// Diagnostic is a single error reported by a build
+type Diagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// Parser extracts a Diagnostic from a line of build output, ok is false for
+// lines that aren't errors
+type Parser func(line string) (d Diagnostic, ok bool)
+
+// Parsers are the build output parsers selectable with a build group's
+// parseErrors field, add to it for other toolchains
+var Parsers = map[string]Parser{
+	"go":  regexParser(regexp.MustCompile(`^(.+?\.go):(\d+)(?::(\d+))?: (.+)$`)),
+	"gcc": regexParser(regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)? (?:fatal )?error: (.+)$`)),
+}
+
+// regexParser returns a Parser for a pattern capturing the file, line,
+// optional column and message
+func regexParser(pattern *regexp.Regexp) Parser {
+	return func(line string) (Diagnostic, bool) {
+
+		m := pattern.FindStringSubmatch(line)
+		if m == nil {
+			return Diagnostic{}, false
+		}
+
+		d := Diagnostic{File: m[1], Message: m[4]}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Column, _ = strconv.Atoi(m[3])
+
+		return d, true
+	}
+}
+
+// reportBuildOutput writes the captured output through on success, on
+// failure it logs each diagnostic found and keeps the raw output at debug
+func (b *Build) reportBuildOutput(parse Parser, output []byte, failed bool, stdout io.Writer) {
+
+	if !failed {
+		stdout.Write(output)
+		return
+	}
+
+	b.log().Debug("build output", "name", b.Name, "output", string(output))
+
+	count := 0
+	lines := bufio.NewScanner(bytes.NewReader(output))
+	for lines.Scan() {
+		d, ok := parse(lines.Text())
+		if !ok {
+			continue
+		}
+		count++
+		b.log().Error("build error", "name", b.Name, "file", d.File, "line", d.Line, "column", d.Column, "message", d.Message)
+	}
+
+	// nothing recognised, don't hide the output
+	if count == 0 {
+		stdout.Write(output)
+		return
+	}
+
+	b.log().Error("build errors", "name", b.Name, "count", count)
+}
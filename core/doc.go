@@ -0,0 +1,21 @@
+// Package core is the engine behind go-live-reload. It watches files, builds
+// and runs build groups, and serves the reverse proxy and static files, and
+// can be embedded to script a custom dev harness.
+//
+// The simplest use is Run with a set of build groups:
+//
+//	err := core.Run(ctx, core.Options{
+//		Builds: []core.Build{{
+//			Name:      "api",
+//			Match:     []string{"*.go"},
+//			BuildCmd:  "go",
+//			BuildArgs: []string{"build", "-o", "build/"},
+//			RunCmd:    "./build/api",
+//		}},
+//	})
+//
+// For finer control create a Supervisor to start, stop and reload groups by
+// name, or call Build, Run and Watch on a single Build. MatchFiles resolves
+// globs the same way the watcher does. Set a Build's Hooks to be called back
+// as it builds, runs and reloads.
+package core
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that reads and writes JSON as a human readable
+// string such as "1s" or "250ms". Plain numbers are still accepted as
+// nanoseconds for older configs.
+//
+//	ex: "heartBeat": "1s" or "heartBeat": 1000000000
+type Duration time.Duration
+
+// String returns the duration formatted like time.Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON writes the duration as a string like "1s"
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads either a duration string or numeric nanoseconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		value, err := time.ParseDuration(text)
+		if err != nil {
+			return err
+		}
+		*d = Duration(value)
+		return nil
+	}
+
+	var nanos int64
+	if err := json.Unmarshal(data, &nanos); err != nil {
+		return fmt.Errorf("duration must be a string like \"1s\" or integer nanoseconds: %s", data)
+	}
+
+	*d = Duration(nanos)
+	return nil
+}
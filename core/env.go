@@ -0,0 +1,157 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// mergeEnv layers each list of KEY=value pairs over the current process
+// environment, later values replacing earlier ones with the same key. It
+// returns nil when every list is nil so the command simply inherits the
+// environment.
+//
+//	ex: env := mergeEnv(b.Env, b.BuildEnv)
+func mergeEnv(lists ...[]string) []string {
+
+	layered := false
+	for _, list := range lists {
+		if list != nil {
+			layered = true
+		}
+	}
+
+	if !layered {
+		return nil
+	}
+
+	env := []string{}
+	index := make(map[string]int) // key => position in env
+
+	for _, list := range append([][]string{os.Environ()}, lists...) {
+		for _, kv := range list {
+			key, _, _ := strings.Cut(kv, "=")
+
+			// windows environment keys are case insensitive
+			if runtime.GOOS == "windows" {
+				key = strings.ToUpper(key)
+			}
+
+			if i, ok := index[key]; ok {
+				env[i] = kv
+				continue
+			}
+
+			index[key] = len(env)
+			env = append(env, kv)
+		}
+	}
+
+	return env
+}
+
+// loadEnvFile parses a dotenv file into KEY=value pairs. Blank lines and
+// lines starting with # are skipped, an optional export prefix is allowed,
+// double quoted values support \n, \t, \" and \\ escapes, single quoted
+// values are taken literally and unquoted values end at a " #" comment.
+//
+//	ex: env, err := loadEnvFile(".env")
+func loadEnvFile(filename string) ([]string, error) {
+
+	data, err := os.ReadFile(filepath.FromSlash(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	env := []string{}
+
+	for n, line := range strings.Split(string(data), "\n") {
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", filename, n+1)
+		}
+
+		value, err = parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, n+1, err)
+		}
+
+		env = append(env, key+"="+value)
+	}
+
+	return env, nil
+}
+
+// parseEnvValue unquotes a single dotenv value
+func parseEnvValue(value string) (string, error) {
+
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", errors.New("unterminated single quote")
+		}
+		return value[1 : end+1], nil
+
+	case strings.HasPrefix(value, `"`):
+		var out strings.Builder
+		escaped := false
+		for _, r := range value[1:] {
+			switch {
+			case escaped:
+				switch r {
+				case 'n':
+					out.WriteRune('\n')
+				case 't':
+					out.WriteRune('\t')
+				default:
+					out.WriteRune(r)
+				}
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				return out.String(), nil
+			default:
+				out.WriteRune(r)
+			}
+		}
+		return "", errors.New("unterminated double quote")
+
+	default:
+		// unquoted values end at an inline comment
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
+
+// envFile loads EnvFile if one is defined, it is read on every call so edits
+// take effect on the next build or run
+func (b *Build) envFile() ([]string, error) {
+
+	if b.EnvFile == "" {
+		return nil, nil
+	}
+
+	env, err := loadEnvFile(b.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+
+	b.log().Debug("envFile loaded", "name", b.Name, "envFile", b.EnvFile, "count", len(env))
+	return env, nil
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"plain", "KEY=value", "KEY=value"},
+		{"export prefix", "export KEY=value", "KEY=value"},
+		{"spaces around", "  KEY = value  ", "KEY=value"},
+		{"empty value", "KEY=", "KEY="},
+		{"equals in value", "URL=postgres://u:p@host/db?sslmode=disable", "URL=postgres://u:p@host/db?sslmode=disable"},
+		{"inline comment", "KEY=value # note", "KEY=value"},
+		{"hash without a space", "COLOR=#fff", "COLOR=#fff"},
+		{"double quoted", `KEY="two words"`, "KEY=two words"},
+		{"double quoted escapes", `KEY="a\nb\t\"c\" \\"`, "KEY=a\nb\t\"c\" \\"},
+		{"double quoted hash", `KEY="a # b" # note`, "KEY=a # b"},
+		{"single quoted literal", `KEY='a\nb $HOME'`, `KEY=a\nb $HOME`},
+		{"single quoted hash", `KEY='a # b'`, "KEY=a # b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			file := filepath.Join(t.TempDir(), ".env")
+			content := "# a comment\n\n" + tt.line + "\n   # indented comment\n"
+			if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadEnvFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("loadEnvFile(%q) = %q, want [%q]", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEnvFileErrors(t *testing.T) {
+
+	for _, line := range []string{"NOEQUALS", "=value", `KEY="open`, `KEY='open`} {
+
+		file := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(file, []byte("OK=1\n"+line+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := loadEnvFile(file)
+		if err == nil {
+			t.Errorf("loadEnvFile accepted %q", line)
+			continue
+		}
+		if !strings.Contains(err.Error(), ":2:") {
+			t.Errorf("error for %q doesn't name line 2: %v", line, err)
+		}
+	}
+}
+
+func TestEnvFilePrecedence(t *testing.T) {
+
+	t.Setenv("GLR_TEST_PROCESS", "process")
+	t.Setenv("GLR_TEST_FILE", "process")
+
+	file := filepath.Join(t.TempDir(), ".env")
+	content := "GLR_TEST_FILE=file\nGLR_TEST_ENV=file\nGLR_TEST_RUN=file\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Build{
+		EnvFile: file,
+		Env:     []string{"GLR_TEST_ENV=env", "GLR_TEST_RUN=env"},
+		RunEnv:  []string{"GLR_TEST_RUN=runEnv"},
+	}
+
+	envFile, err := b.envFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the order run uses: envFile, then env, then runEnv
+	env := mergeEnv(envFile, b.Env, b.RunEnv)
+
+	for _, want := range []string{
+		"GLR_TEST_PROCESS=process", // untouched by the others
+		"GLR_TEST_FILE=file",       // envFile over the process
+		"GLR_TEST_ENV=env",         // env over envFile
+		"GLR_TEST_RUN=runEnv",      // runEnv over both
+	} {
+		if !slices.Contains(env, want) {
+			t.Errorf("merged env is missing %s", want)
+		}
+	}
+}
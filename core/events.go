@@ -0,0 +1,56 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event is a machine-readable lifecycle event written as a line of JSON so
+// editors and TUIs can render their own view of the build groups
+type Event struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"` // build_start, build_ok, build_fail, run_start or reload
+	Name     string    `json:"name"`
+	Error    string    `json:"error,omitzero"`
+	Duration Duration  `json:"duration,omitzero"`
+	Files    int       `json:"files,omitzero"`
+	Manual   bool      `json:"manual,omitzero"`
+}
+
+// EventHooks returns Hooks writing an Event per line to w, safe to share
+// between build groups
+//
+//	ex: b.Hooks = EventHooks(os.Stdout)
+func EventHooks(w io.Writer) Hooks {
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+
+	emit := func(e Event) {
+		e.Time = time.Now()
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(e)
+	}
+
+	return Hooks{
+		OnBuildStart: func(name string) {
+			emit(Event{Type: "build_start", Name: name})
+		},
+		OnBuildDone: func(name string, err error, duration time.Duration) {
+			if err != nil {
+				emit(Event{Type: "build_fail", Name: name, Error: err.Error(), Duration: Duration(duration)})
+				return
+			}
+			emit(Event{Type: "build_ok", Name: name, Duration: Duration(duration)})
+		},
+		OnRunStart: func(name string) {
+			emit(Event{Type: "run_start", Name: name})
+		},
+		OnReload: func(name string, change Change) {
+			emit(Event{Type: "reload", Name: name, Files: len(change.Added) + len(change.Removed) + len(change.Modified), Manual: change.Manual})
+		},
+	}
+}
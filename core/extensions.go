@@ -0,0 +1,107 @@
+package core
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Extensions are a friendlier alternative to globs: each one watches every
+// file with that extension anywhere under WatchDir. Their results are added
+// to whatever Match finds and Ignore removes paths from both.
+
+// patterns returns the Match globs, without negations, followed by the
+// Extensions, the order scan returns its results in and the names reported
+// in Change.Globs
+func (b *Build) patterns() []string {
+	return slices.Concat(includes(b.Match), b.Extensions)
+}
+
+// watchDir returns the root walked for Extensions, default "."
+func (b *Build) watchDir() string {
+	if b.WatchDir == "" {
+		return "."
+	}
+	return filepath.Clean(filepath.FromSlash(b.WatchDir))
+}
+
+// matchExtension walks WatchDir for files ending in ext, skipping hidden
+// directories like .git and anything ignored or excluded
+//
+//	ex: files := b.matchExtension(".go")
+func (b *Build) matchExtension(ext string) map[string]fs.FileInfo {
+
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	root := b.watchDir()
+	matches := make(map[string]fs.FileInfo)
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+
+		// ignore I/O errors like filepath.Glob
+		if err != nil {
+			return nil
+		}
+
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || b.groups.ignoredFile(path, true) || b.ignored(path) || b.excluded(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(path) != ext {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		matches[path] = info
+		return nil
+	})
+
+	return matches
+}
+
+// ignored reports whether the IgnoreFile excludes path, a "!" entry in Match
+// has the last word on it or an Ignore pattern matches path, its base name or
+// any of its parent directories
+//
+//	ex: ["node_modules", "*_test.go", "build/*"]
+func (b *Build) ignored(path string) bool {
+
+	if b.groups.ignoredFile(path, false) {
+		return true
+	}
+
+	if negated(b.Match, path) {
+		return true
+	}
+
+	if len(b.Ignore) == 0 {
+		return false
+	}
+
+	for p := filepath.Clean(path); p != "." && p != string(filepath.Separator); p = filepath.Dir(p) {
+		for _, pattern := range b.Ignore {
+			pattern = filepath.FromSlash(pattern)
+			if matched, _ := filepath.Match(pattern, p); matched {
+				return true
+			}
+			if matched, _ := filepath.Match(pattern, filepath.Base(p)); matched {
+				return true
+			}
+		}
+		if filepath.Dir(p) == p {
+			break
+		}
+	}
+
+	return false
+}
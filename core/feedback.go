@@ -0,0 +1,132 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// A build that writes into a directory it also watches restarts itself every
+// time it builds. CheckFeedback looks for that overlap at startup and keeps
+// the build output out of the watch.
+
+// outputPath infers where the build writes from OutDir or a -o flag in
+// BuildArgs, relative to the tool's working directory. isDir is true when
+// the output is a directory rather than a single file.
+//
+//	ex: buildDir "." with buildArgs [build -o build/] => "build", true
+func (b *Build) outputPath() (path string, isDir bool, ok bool) {
+
+	if b.OutDir != "" {
+		return filepath.Clean(filepath.FromSlash(b.OutDir)), true, true
+	}
+
+	out := ""
+	for i, arg := range b.BuildArgs {
+		if arg == "-o" && i+1 < len(b.BuildArgs) {
+			out = b.BuildArgs[i+1]
+		} else if value, found := strings.CutPrefix(arg, "-o="); found {
+			out = value
+		}
+	}
+
+	if out == "" {
+		return "", false, false
+	}
+
+	// go build treats a trailing slash as an output directory
+	isDir = strings.HasSuffix(out, "/") || strings.HasSuffix(out, `\`)
+
+	out = filepath.FromSlash(out)
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(filepath.FromSlash(b.BuildDir), out)
+	}
+
+	return filepath.Clean(out), isDir, true
+}
+
+// CheckFeedback warns when a Match glob overlaps the build output, which
+// would restart the group every time it builds, and excludes the output from
+// watching. With strict set an overlap is returned as an error instead.
+//
+//	ex: err := b.CheckFeedback(false)
+func (b *Build) CheckFeedback(strict bool) error {
+
+	out, isDir, ok := b.outputPath()
+	if !ok {
+		return nil
+	}
+
+	for _, glob := range b.patterns() {
+
+		if !b.overlaps(glob, out, isDir) {
+			continue
+		}
+
+		if strict {
+			return fmt.Errorf("build group %q watches %q which overlaps its build output %q", b.Name, glob, out)
+		}
+
+		b.log().Warn("match overlaps build output, excluding it from the watch", "name", b.Name, "glob", glob, "output", out)
+		b.excludes = append(b.excludes, out)
+		return nil
+	}
+
+	return nil
+}
+
+// overlaps reports whether a Match glob or one of the Extensions can see out,
+// an extension sees everything under WatchDir
+func (b *Build) overlaps(pattern, out string, isDir bool) bool {
+
+	if !slices.Contains(b.Extensions, pattern) {
+		return globOverlaps(filepath.Clean(filepath.FromSlash(pattern)), out, isDir)
+	}
+
+	root := b.watchDir()
+	if !isDir && filepath.Ext(out) != pattern && filepath.Ext(out) != "."+pattern {
+		return false
+	}
+
+	if root == "." {
+		return !filepath.IsAbs(out) && !strings.HasPrefix(out, "..")
+	}
+
+	return out == root || strings.HasPrefix(out, root+string(filepath.Separator))
+}
+
+// globOverlaps reports whether glob can match out or, for an output
+// directory, anything within it
+func globOverlaps(glob, out string, isDir bool) bool {
+
+	if matched, _ := filepath.Match(glob, out); matched {
+		return true
+	}
+
+	if !isDir {
+		return false
+	}
+
+	// the glob's directory is the output directory or somewhere inside it
+	globDir := filepath.Dir(glob)
+	if matched, _ := filepath.Match(globDir, out); matched {
+		return true
+	}
+
+	return globDir == out || strings.HasPrefix(globDir, out+string(filepath.Separator))
+}
+
+// excluded reports whether path falls within an excluded build output
+func (b *Build) excluded(path string) bool {
+
+	path = filepath.Clean(path)
+
+	for _, out := range b.excludes {
+		if path == out || strings.HasPrefix(path, out+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
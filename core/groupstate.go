@@ -0,0 +1,45 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// groupState is what build groups share at runtime with each other and with
+// the reverse proxy following them, keyed by build group name. Each Config
+// and Supervisor owns one rather than keeping it in package variables, so
+// programs embedding several of them in one process keep them apart. A Build
+// started on its own, outside a Supervisor, has none and shares nothing.
+type groupState struct {
+	ports  sync.Map // name to the port serving traffic, see bluegreen.go
+	gates  sync.Map // name to its *readyGate, see ready.go
+	phases sync.Map // name to its phase, see status.go
+	built  sync.Map // name to when its last build finished, see settle.go
+
+	live   *liveBroker                // live reload browsers, see livereload.go
+	liveOn atomic.Bool                // set once the reverse proxy serves live reload
+	ignore atomic.Pointer[ignoreFile] // the loaded IgnoreFile, see ignorefile.go
+}
+
+// newGroupState returns an empty groupState
+func newGroupState() *groupState {
+	return &groupState{live: newLiveBroker()}
+}
+
+// shared returns the state the Config's servers share with its build groups,
+// created on first use
+func (c *Config) shared() *groupState {
+	if c.groups == nil {
+		c.groups = newGroupState()
+	}
+	return c.groups
+}
+
+// attach hands the Config's state to each of its build groups, so copies of
+// them passed to a Supervisor are followed by the Config's reverse proxy
+func (c *Config) attach() {
+	groups := c.shared()
+	for i := range c.Builds {
+		c.Builds[i].groups = groups
+	}
+}
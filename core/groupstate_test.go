@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGroupStateKeptPerConfig(t *testing.T) {
+
+	first := &Config{Builds: []Build{{Name: "api"}}}
+	second := &Config{Builds: []Build{{Name: "api"}}}
+	first.attach()
+	second.attach()
+
+	// both configs name their group api, as two embedders might
+	NewSupervisor(context.Background(), first.Builds)
+	NewSupervisor(context.Background(), second.Builds)
+
+	b := first.Builds[0]
+	b.groups.setActivePort(b.Name, "8081")
+	b.setPhase(phaseRunning)
+	b.groups.markReady(b.Name)
+
+	if port, ok := first.ActivePort("api"); !ok || port != "8081" {
+		t.Errorf("first ActivePort = %q, %v, want 8081", port, ok)
+	}
+	if port, ok := second.ActivePort("api"); ok {
+		t.Errorf("second config sees the first's port %q", port)
+	}
+	if _, ok := second.shared().phase("api"); ok {
+		t.Error("second config sees the first's phase")
+	}
+	if second.shared().isReady("api") {
+		t.Error("second config sees the first's ready gate")
+	}
+}
+
+func TestSupervisorSharesStateWithoutConfig(t *testing.T) {
+
+	builds := []Build{{Name: "api"}, {Name: "web"}}
+	s := NewSupervisor(context.Background(), builds)
+
+	if builds[0].groups == nil || builds[0].groups != builds[1].groups || builds[0].groups != s.shared {
+		t.Error("builds outside a Config don't share the supervisor's state")
+	}
+
+	other := []Build{{Name: "api"}}
+	NewSupervisor(context.Background(), other)
+	if other[0].groups == builds[0].groups {
+		t.Error("two supervisors share one state")
+	}
+}
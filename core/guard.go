@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// restartCooldown is how long a group pauses after too many restarts
+const restartCooldown = 1 * time.Minute
+
+// restartGuard tracks recent restarts to catch a rebuild storm, usually a
+// glob matching files the build itself produces or a crash loop
+type restartGuard struct {
+	times []time.Time
+}
+
+// allow records a restart and reports whether the restarts within the last
+// minute are still at or under max, a max below one disables the guard
+func (g *restartGuard) allow(max int) bool {
+
+	if max < 1 {
+		return true
+	}
+
+	now := time.Now()
+	g.times = append(g.times, now)
+
+	// forget anything older than a minute
+	recent := g.times[:0]
+	for _, t := range g.times {
+		if now.Sub(t) < time.Minute {
+			recent = append(recent, t)
+		}
+	}
+	g.times = recent
+
+	return len(g.times) <= max
+}
+
+// reset forgets all recorded restarts
+func (g *restartGuard) reset() {
+	g.times = nil
+}
+
+// cooldown pauses the group after a restart storm, draining the restart
+// channel until restartCooldown passes or a manual reload arrives. It returns
+// false if ctx ends first.
+func (b *Build) cooldown(ctx context.Context, restart chan Change) bool {
+
+	b.log().Error("restart delayed", b.delayed("throttled", restartCooldown, "too many restarts: check match globs for generated files or a crash loop", "maxRestartsPerMinute", b.MaxRestartsPerMinute)...)
+
+	timer := time.NewTimer(restartCooldown)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			b.log().Info("restart resumed", b.resumed("throttled", "cooldown over")...)
+			return true
+		case change := <-restart:
+			if change.Manual {
+				b.log().Info("restart resumed", b.resumed("throttled", "manual reload")...)
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"time"
+)
+
+// Hooks are optional callbacks on a Build for programs embedding the
+// reloader, letting them drive a UI, send notifications or trigger a browser
+// refresh without forking. A nil hook is skipped.
+//
+// Hooks are called synchronously from the build group's Start goroutine, so
+// a slow hook delays that group and nothing else. Different build groups call
+// their hooks concurrently, a hook shared between groups must be safe for
+// concurrent use.
+//
+//	ex: b.Hooks.OnRunStart = func(name string) { notify(name + " is up") }
+type Hooks struct {
+
+	// OnBuildStart is called before each build
+	OnBuildStart func(name string)
+
+	// OnBuildDone is called after each build with its error, if any, and how
+	// long it took
+	OnBuildDone func(name string, err error, duration time.Duration)
+
+	// OnRunStart is called each time the run command is launched
+	OnRunStart func(name string)
+
+	// OnReload is called when a change is about to restart the group
+	OnReload func(name string, change Change)
+}
+
+// buildStart calls OnBuildStart if set
+func (h Hooks) buildStart(name string) {
+	if h.OnBuildStart != nil {
+		h.OnBuildStart(name)
+	}
+}
+
+// buildDone calls OnBuildDone if set
+func (h Hooks) buildDone(name string, err error, duration time.Duration) {
+	if h.OnBuildDone != nil {
+		h.OnBuildDone(name, err, duration)
+	}
+}
+
+// runStart calls OnRunStart if set
+func (h Hooks) runStart(name string) {
+	if h.OnRunStart != nil {
+		h.OnRunStart(name)
+	}
+}
+
+// reload calls OnReload if set
+func (h Hooks) reload(name string, change Change) {
+	if h.OnReload != nil {
+		h.OnReload(name, change)
+	}
+}
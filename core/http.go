@@ -1,12 +1,17 @@
 package core
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
+	"os"
+	"slices"
 	"strings"
+	"time"
 )
 
 // HttpTarget is a reverse proxy target
@@ -22,22 +27,152 @@ type HttpTarget struct {
 
 	// InsecureSkipVerify is a flag to enable or disable TLS verification downstream
 	InsecureSkipVerify bool `json:"insecureSkipVerify,omitzero"`
+
+	// Hosts are more upstreams for the route, requests take turns between
+	// Host and each of these, see sticky.go
+	// ex: ["http://localhost:8083", "http://localhost:8084"]
+	Hosts []string `json:"hosts,omitzero"`
+
+	// Sticky pins each client to the upstream it first reached with a
+	// cookie, for backends keeping sessions in memory
+	Sticky *Sticky `json:"sticky,omitzero"`
+
+	// BuildGroup follows the active blue/green or auto-selected port of the
+	// named build group, replacing the port in Host
+	// ex: "webserver"
+	BuildGroup string `json:"buildGroup,omitzero"`
+
+	// DialTimeout bounds connecting to the target, default 5s
+	// ex: "2s"
+	DialTimeout Duration `json:"dialTimeout,omitzero"`
+
+	// ResponseHeaderTimeout bounds waiting on the target's response headers
+	// once the request is sent, default 30s
+	// ex: "10s"
+	ResponseHeaderTimeout Duration `json:"responseHeaderTimeout,omitzero"`
+
+	// RequestTimeout bounds the whole request including the response body.
+	// It is off by default so streams and websockets aren't cut short.
+	// ex: "1m"
+	RequestTimeout Duration `json:"requestTimeout,omitzero"`
+
+	// RebuildPage answers with a 503 page refreshing itself until the
+	// BuildGroup is running again, instead of a 502 while it rebuilds or
+	// after a failed build, see status.go
+	RebuildPage bool `json:"rebuildPage,omitzero"`
+
+	// MaxBodyBytes rejects request bodies larger than this with a 413 before
+	// they reach the target, to match a production limit. Unset is unlimited.
+	// ex: 10485760
+	MaxBodyBytes int64 `json:"maxBodyBytes,omitzero"`
+}
+
+// default proxy timeouts, short enough that a backend stuck mid-restart
+// fails fast instead of hanging the browser
+const (
+	defaultDialTimeout           = 5 * time.Second
+	defaultResponseHeaderTimeout = 30 * time.Second
+)
+
+// transport returns the http.Transport for the target with its timeouts
+// applied
+func (t HttpTarget) transport() *http.Transport {
+
+	dialTimeout := time.Duration(t.DialTimeout)
+	if dialTimeout <= 0 {
+		dialTimeout = defaultDialTimeout
+	}
+
+	headerTimeout := time.Duration(t.ResponseHeaderTimeout)
+	if headerTimeout <= 0 {
+		headerTimeout = defaultResponseHeaderTimeout
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		ResponseHeaderTimeout: headerTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: t.InsecureSkipVerify,
+		},
+	}
+}
+
+// withTimeout bounds every request through next by timeout
+func withTimeout(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// withMaxBody rejects requests to next whose body is larger than limit. A
+// declared Content-Length is refused up front, a chunked body is cut off
+// once it passes the limit and the proxy's ErrorHandler answers 413.
+func withMaxBody(limit int64, log *slog.Logger, path string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			log.Warn("reverse-proxy request body too large", "path", path, "url", r.URL.Path, "contentLength", r.ContentLength, "maxBodyBytes", limit)
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readyGated reports whether requests for group wait on its readyCheck, which
+// needs the group to be among the running groups and have one
+func (c *Config) readyGated(group string, groups []string) bool {
+
+	if !slices.Contains(groups, group) {
+		return false
+	}
+
+	i := slices.IndexFunc(c.Builds, func(b Build) bool { return b.Name == group })
+	return i >= 0 && c.Builds[i].ReadyCheck != ""
+}
+
+// protocols returns the server protocols, adding plaintext HTTP/2 (h2c) to
+// HTTP/1 and TLS HTTP/2 when enabled. Only prior knowledge h2c is supported,
+// clients relying on an "Upgrade: h2c" request stay on HTTP/1.
+func protocols(h2c bool) *http.Protocols {
+
+	p := &http.Protocols{}
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	p.SetUnencryptedHTTP2(h2c)
+
+	return p
 }
 
-// RunProxy starts a reverse proxy server
-func (c *Config) RunProxy() {
+// RunProxy starts a reverse proxy server. Groups names the build groups that
+// will run, like those picked by --build-groups, and only their readyCheck
+// holds early requests; a group that never starts would otherwise stall its
+// path for the full wait.
+//
+//	ex: go config.RunProxy("frontend", "backend")
+func (c *Config) RunProxy(groups ...string) {
 
-	slog.Info("reverse-proxy init")
+	log := c.log()
+	log.Info("reverse-proxy init")
+
+	// the state the build groups report their ports and readiness through
+	shared := c.shared()
 
 	mux := http.NewServeMux()
+	routes := make(map[string]http.Handler)
 
 	// add each reverse proxy target to our MIX
 	for path, target := range c.ReverseProxy {
 
-		// parse the target into a URL (scheme, host, port)
-		url, err := url.Parse(target.Host)
+		// parse the target's upstreams into URLs (scheme, host, port)
+		lb, err := newBalancer(path, target, shared)
 		if err != nil {
-			slog.Error("reverse-proxy", "error", err, "target", target)
+			log.Error("reverse-proxy", "error", err, "target", target)
 			return
 		}
 
@@ -46,8 +181,29 @@ func (c *Config) RunProxy() {
 
 			// ErrorHandler is a function that is called when the reverse proxy encounters an error
 			ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
-				slog.Error("reverse-proxy", "path", path, "host", target.Host, "error", err)
-				http.Error(w, err.Error(), http.StatusBadGateway)
+				// a body cut off by maxBodyBytes is the client's fault
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					log.Warn("reverse-proxy request body too large", "path", path, "url", r.URL.Path, "maxBodyBytes", tooLarge.Limit)
+					http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+					return
+				}
+
+				// a backend down for a rebuild gets a page that waits it out
+				if phase, ok := shared.phase(target.BuildGroup); ok && target.RebuildPage && phase != phaseRunning {
+					log.Info("reverse-proxy upstream down", "path", path, "build-group", target.BuildGroup, "phase", phase)
+					serveRebuilding(w, r, target.BuildGroup, phase)
+					return
+				}
+
+				log.Error("reverse-proxy", "path", path, "host", r.URL.Host, "error", err)
+
+				// a timeout is the backend's fault rather than a bad gateway
+				status := http.StatusBadGateway
+				if errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err) {
+					status = http.StatusGatewayTimeout
+				}
+				http.Error(w, err.Error(), status)
 			},
 
 			// Director is an (oddly named) function that modifies the request before it is sent
@@ -55,66 +211,99 @@ func (c *Config) RunProxy() {
 
 				// add any custom headers to the request
 				for k, v := range target.CustomHeaders {
-					slog.Debug("reverse-proxy add header", "key", k, "value", v)
+					log.Debug("reverse-proxy add header", "key", k, "value", v)
 					r.Header.Add(k, v)
 				}
 
 				incoming := r.URL.Path
 
 				// TODO: this still feels too clunky, selectively manipulating the request
-				r.URL.Scheme = url.Scheme
-				r.URL.Host = url.Host
+				// pick the upstream, following the build group's blue/green
+				// swaps if configured
+				lb.pick(r)
 				r.URL.Path = strings.TrimPrefix(incoming, "/api")
 
 				if !strings.HasPrefix(r.URL.Path, "/") {
 					r.URL.Path = "/" + r.URL.Path
 				}
 
-				slog.Info("reverse-proxy", "path", path, "host", target.Host, "incoming", incoming, "downstream", r.URL.Path)
+				log.Info("reverse-proxy", "path", path, "host", r.URL.Host, "incoming", incoming, "downstream", r.URL.Path)
 
 			},
+
+			// ModifyResponse pins the client to its upstream when sticky
+			ModifyResponse: lb.pin,
 		}
 
-		// set the transport to allow insecure connections
-		proxy.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: target.InsecureSkipVerify,
-			},
+		// set the transport timeouts and allow insecure connections if asked
+		proxy.Transport = target.transport()
+
+		// hold early requests until the upstream build group is ready
+		var handler http.Handler = proxy
+		if c.readyGated(target.BuildGroup, groups) {
+			handler = shared.waitUpstream(log, path, target.BuildGroup, proxy)
 		}
-		mux.Handle(path, proxy)
-		slog.Info("reverse-proxy handle", "path", path, "host", target.Host)
+
+		if target.RequestTimeout > 0 {
+			handler = withTimeout(time.Duration(target.RequestTimeout), handler)
+		}
+
+		if target.MaxBodyBytes > 0 {
+			handler = withMaxBody(target.MaxBodyBytes, log, path, handler)
+		}
+
+		mux.Handle(path, handler)
+		routes[path] = handler
+		log.Info("reverse-proxy handle", "path", path, "host", target.Host)
+	}
+
+	// stream build results to pages including the live reload client
+	if c.LiveReload {
+		mux.Handle("/__glr/events", shared.live)
+		mux.HandleFunc("/__glr/client.js", serveLiveClient)
+		shared.liveOn.Store(true)
+		log.Info("reverse-proxy live reload", "script", "/__glr/client.js")
+	}
+
+	// serve the static site from the same origin when it shares our listener
+	var handler http.Handler = mux
+	if c.Combined() {
+		var closeAll func()
+		handler, closeAll = c.router(mux, routes, log)
+		defer closeAll()
 	}
 
 	server := &http.Server{
-		Addr:    c.Bind,
-		Handler: mux,
+		Addr:      c.Bind,
+		Handler:   c.BasicAuth.wrap(handler, log),
+		Protocols: protocols(c.H2C),
 	}
 
-	slog.Info("reverse-proxy listen", "addr", server.Addr)
+	log.Info("reverse-proxy listen", "addr", server.Addr)
 
 	// both cert and key are needed, warn the user if they are not set
 	if c.TLSCertFile == "" && c.TLSKeyFile != "" {
-		slog.Warn("reverse-proxy tls", "cert", "not set", "key", c.TLSKeyFile)
+		log.Warn("reverse-proxy tls", "cert", "not set", "key", c.TLSKeyFile)
 	} else if c.TLSCertFile != "" && c.TLSKeyFile == "" {
-		slog.Warn("reverse-proxy tls", "cert", c.TLSCertFile, "key", "not set")
+		log.Warn("reverse-proxy tls", "cert", c.TLSCertFile, "key", "not set")
 	}
 
 	// if both cert and key are set, start the server with TLS
 	if c.TLSCertFile != "" && c.TLSKeyFile != "" {
-		slog.Info("reverse-proxy tls", "cert", c.TLSCertFile, "key", c.TLSKeyFile)
+		log.Info("reverse-proxy tls", "cert", c.TLSCertFile, "key", c.TLSKeyFile)
 		err := server.ListenAndServeTLS(c.TLSCertFile, c.TLSKeyFile)
 		if err != nil {
-			slog.Error("reverse-proxy tls", "error", err)
+			log.Error("reverse-proxy tls", "error", err)
 			return
 		}
 		// otherwise, start the server without TLS
 	} else {
 		err := server.ListenAndServe()
 		if err != nil {
-			slog.Error("reverse-proxy", "error", err)
+			log.Error("reverse-proxy", "error", err)
 			return
 		}
 	}
-	slog.Info("reverse-proxy shutdown")
+	log.Info("reverse-proxy shutdown")
 
 }
@@ -0,0 +1,30 @@
+package core
+
+import "testing"
+
+func TestReadyGatedOnlyRunningGroups(t *testing.T) {
+
+	c := &Config{Builds: []Build{
+		{Name: "api", ReadyCheck: "http://localhost:8081/health"},
+		{Name: "worker", ReadyCheck: "http://localhost:8082/health"},
+		{Name: "web"},
+	}}
+
+	tests := []struct {
+		group   string
+		running []string
+		want    bool
+	}{
+		{"api", []string{"api", "web"}, true},
+		{"worker", []string{"api", "web"}, false}, // left out by --build-groups
+		{"web", []string{"api", "web"}, false},    // no readyCheck
+		{"missing", []string{"missing"}, false},   // not a build group
+		{"api", nil, false},                       // nothing runs
+	}
+
+	for _, tt := range tests {
+		if got := c.readyGated(tt.group, tt.running); got != tt.want {
+			t.Errorf("readyGated(%q, %v) = %v, want %v", tt.group, tt.running, got, tt.want)
+		}
+	}
+}
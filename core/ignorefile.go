@@ -0,0 +1,233 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IgnoreFile is read from the working directory for watch exclusions shared
+// by every build group, kept apart from the config and from the VCS's own
+// ignore rules. It uses gitignore syntax:
+//
+//	# comments and blank lines are skipped
+//	*.log          matches a name in any directory
+//	/tmp           a leading or inner slash anchors to the working directory
+//	build/         a trailing slash only matches directories
+//	docs/**/*.png  ** matches any number of directories
+//	!keep.log      re-includes a path an earlier line ignored
+//
+// As in git a path inside an ignored directory can't be re-included. A
+// group's Ignore is applied on top, a path either ignores is left out.
+const IgnoreFile = ".glrignore"
+
+// ignoreFile is a parsed IgnoreFile
+type ignoreFile struct {
+	rules []ignoreRule
+	root  string // absolute working directory the rules are relative to
+}
+
+// ignoreRule is one line of an IgnoreFile
+type ignoreRule struct {
+	segments []string // pattern split on "/"
+	anchored bool     // matches from the root rather than any name
+	dirOnly  bool     // trailing slash, only matches directories
+	negate   bool     // leading "!", re-includes a match
+}
+
+// LoadIgnoreFile reads name for the watch exclusions used by every build
+// group of the Config, replacing any loaded before. A missing file clears
+// them.
+//
+//	ex: err := config.LoadIgnoreFile(core.IgnoreFile)
+func (c *Config) LoadIgnoreFile(name string) error {
+
+	file, err := os.Open(filepath.FromSlash(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		c.shared().ignore.Store(nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	root, err := filepath.Abs(".")
+	if err != nil {
+		return err
+	}
+
+	ig := &ignoreFile{root: root}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+			ig.rules = append(ig.rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	c.shared().ignore.Store(ig)
+	c.log().Info("ignore file loaded", "file", name, "rules", len(ig.rules))
+
+	return nil
+}
+
+// WatchIgnoreFile reloads name every heartBeat its modtime changes, until ctx
+// is cancelled
+//
+//	ex: go config.WatchIgnoreFile(ctx, core.IgnoreFile, time.Second)
+func (c *Config) WatchIgnoreFile(ctx context.Context, name string, heartBeat time.Duration) {
+
+	modTime := func() time.Time {
+		info, err := os.Stat(filepath.FromSlash(name))
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+	last := modTime()
+
+	tick := time.NewTicker(heartBeat)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			current := modTime()
+			if current.Equal(last) {
+				continue
+			}
+			last = current
+
+			err := c.LoadIgnoreFile(name)
+			if err != nil {
+				c.log().Error("ignore file", "file", name, "error", err)
+			}
+		}
+	}
+}
+
+// parseIgnoreRule parses one line, ok is false for comments and blank lines
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		rule.negate = true
+		line = rest
+	}
+	// \# and \! escape a literal first character
+	line = strings.TrimPrefix(line, `\`)
+
+	if rest, ok := strings.CutSuffix(line, "/"); ok {
+		rule.dirOnly = true
+		line = rest
+	}
+
+	rule.anchored = strings.Contains(line, "/")
+	rule.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+
+	return rule, line != ""
+}
+
+// ignoredFile reports whether the loaded IgnoreFile excludes path, isDir
+// tells whether path itself is a directory
+func (g *groupState) ignoredFile(name string, isDir bool) bool {
+
+	if g == nil {
+		return false
+	}
+	ig := g.ignore.Load()
+	if ig == nil {
+		return false
+	}
+
+	// rules are relative to the working directory they were loaded from
+	name = filepath.Clean(name)
+	if filepath.IsAbs(name) {
+		rel, err := filepath.Rel(ig.root, name)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return false
+		}
+		name = rel
+	}
+	if name == "." {
+		return false
+	}
+
+	// a directory ignored on the way down can't be re-included below
+	parts := strings.Split(filepath.ToSlash(name), "/")
+	for i := 1; i <= len(parts); i++ {
+		if ig.match(parts[:i], i < len(parts) || isDir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match applies every rule in order, the last one matching wins
+func (ig *ignoreFile) match(parts []string, isDir bool) bool {
+
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.matches(parts) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+// matches reports whether the rule matches the path split into parts
+func (rule ignoreRule) matches(parts []string) bool {
+
+	if !rule.anchored {
+		matched, _ := path.Match(rule.segments[0], parts[len(parts)-1])
+		return matched
+	}
+
+	return matchSegments(rule.segments, parts)
+}
+
+// matchSegments matches pattern segments against path segments, a "**"
+// segment matching any number of them
+func matchSegments(pattern, parts []string) bool {
+
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+
+	matched, _ := path.Match(pattern[0], parts[0])
+	return matched && matchSegments(pattern[1:], parts[1:])
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreFileRules(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		rules []string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"name in any directory", []string{"*.log"}, "logs/app/today.log", false, true},
+		{"name not matching", []string{"*.log"}, "main.go", false, false},
+		{"leading slash anchors", []string{"/tmp"}, "tmp", true, true},
+		{"leading slash not nested", []string{"/tmp"}, "cache/tmp", true, false},
+		{"inner slash anchors", []string{"docs/*.md"}, "docs/a.md", false, true},
+		{"inner slash not nested", []string{"docs/*.md"}, "site/docs/a.md", false, false},
+		{"dirOnly matches a directory", []string{"build/"}, "build", true, true},
+		{"dirOnly skips a file", []string{"build/"}, "build", false, false},
+		{"dirOnly covers its contents", []string{"build/"}, "build/app.exe", false, true},
+		{"double star no directories", []string{"docs/**/*.png"}, "docs/a.png", false, true},
+		{"double star many directories", []string{"docs/**/*.png"}, "docs/x/y/a.png", false, true},
+		{"double star other root", []string{"docs/**/*.png"}, "site/x/a.png", false, false},
+		{"re-include", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"re-include leaves others", []string{"*.log", "!keep.log"}, "other.log", false, true},
+		{"last rule wins", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"no re-include under an ignored dir", []string{"vendor/", "!vendor/keep.go"}, "vendor/keep.go", false, true},
+		{"comment skipped", []string{"# notes"}, "# notes", false, false},
+		{"escaped hash", []string{`\#notes`}, "#notes", false, true},
+		{"escaped bang", []string{`\!important`}, "!important", false, true},
+		{"trailing spaces trimmed", []string{"*.tmp  "}, "a.tmp", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Chdir(t.TempDir())
+			err := os.WriteFile(IgnoreFile, []byte(strings.Join(tt.rules, "\n")+"\n"), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := &Config{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+			if err := c.LoadIgnoreFile(IgnoreFile); err != nil {
+				t.Fatal(err)
+			}
+
+			got := c.shared().ignoredFile(filepath.FromSlash(tt.path), tt.isDir)
+			if got != tt.want {
+				t.Errorf("ignoredFile(%q, %v) with %q = %v, want %v", tt.path, tt.isDir, tt.rules, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreFileMissingClears(t *testing.T) {
+
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile(IgnoreFile, []byte("*.log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if err := c.LoadIgnoreFile(IgnoreFile); err != nil {
+		t.Fatal(err)
+	}
+	if !c.shared().ignoredFile("a.log", false) {
+		t.Fatal("a.log not ignored once loaded")
+	}
+
+	// absolute paths under the working directory match too
+	abs, _ := filepath.Abs("a.log")
+	if !c.shared().ignoredFile(abs, false) {
+		t.Error("absolute path under the root not ignored")
+	}
+
+	os.Remove(IgnoreFile)
+	if err := c.LoadIgnoreFile(IgnoreFile); err != nil {
+		t.Fatal(err)
+	}
+	if c.shared().ignoredFile("a.log", false) {
+		t.Error("rules kept after the ignore file was removed")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package core
+
+import (
+	"log/slog"
+	"os/exec"
+)
+
+// killTreeOnClose is only needed on windows, see job_windows.go
+func killTreeOnClose(cmd *exec.Cmd, log *slog.Logger) func() {
+	return func() {}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"log/slog"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// On windows killing the run process leaves anything it spawned running, an
+// old server started by a wrapper script keeps its port and the next instance
+// fails to bind. Each process is assigned to a job object that kills every
+// process in it when the job's handle is closed.
+//
+// To test by hand, use a runCmd that spawns a child holding a port, such as
+// `cmd /c go run .`, then save a watched file: the old server should be gone
+// before the new one starts and nothing should be left in Task Manager after
+// exiting. A child spawned in the instant between starting the process and
+// assigning it to the job can escape.
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+)
+
+const (
+	jobObjectExtendedLimitInformation = 9
+	jobObjectLimitKillOnJobClose      = 0x2000
+	processSetQuota                   = 0x0100
+	processTerminate                  = 0x0001
+)
+
+// jobBasicLimitInformation is JOBOBJECT_BASIC_LIMIT_INFORMATION
+type jobBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+// jobExtendedLimitInformation is JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+type jobExtendedLimitInformation struct {
+	BasicLimitInformation jobBasicLimitInformation
+	IoInfo                [6]uint64 // IO_COUNTERS
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// killTreeOnClose assigns the started cmd to a new job object, the returned
+// func closes the job killing anything the process spawned that is still
+// running. Failures are logged and leave the process outside any job.
+func killTreeOnClose(cmd *exec.Cmd, log *slog.Logger) func() {
+
+	job, _, err := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		log.Warn("job object", "error", err)
+		return func() {}
+	}
+
+	info := jobExtendedLimitInformation{}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+
+	ok, _, err := procSetInformationJobObject.Call(job, jobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info))
+	if ok == 0 {
+		log.Warn("job object", "error", err)
+		syscall.CloseHandle(syscall.Handle(job))
+		return func() {}
+	}
+
+	process, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		log.Warn("job object", "error", err)
+		syscall.CloseHandle(syscall.Handle(job))
+		return func() {}
+	}
+	defer syscall.CloseHandle(process)
+
+	ok, _, err = procAssignProcessToJobObject.Call(job, uintptr(process))
+	if ok == 0 {
+		log.Warn("job object", "error", err)
+		syscall.CloseHandle(syscall.Handle(job))
+		return func() {}
+	}
+
+	return func() { syscall.CloseHandle(syscall.Handle(job)) }
+}
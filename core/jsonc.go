@@ -0,0 +1,93 @@
+package core
+
+// stripJSONC converts JSON with comments into strict JSON by removing line
+// and block comments along with trailing commas before a closing bracket or
+// brace. String contents are left untouched.
+//
+//	ex: stripJSONC([]byte(`{"a": 1, // note` + "\n}")) => {"a": 1 \n}
+func stripJSONC(data []byte) []byte {
+
+	out := make([]byte, 0, len(data))
+
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out = append(out, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+
+		// line comment, skip to the end of the line
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+
+		// block comment, skip past the closing */ keeping newlines so any
+		// json errors still point at the right line
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				if data[i] == '\n' {
+					out = append(out, '\n')
+				}
+				i++
+			}
+			i++
+
+		// drop a comma if only whitespace or comments remain before ] or }
+		case c == ',' && trailingComma(data[i+1:]):
+
+		default:
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+// trailingComma reports whether rest begins with whitespace and comments
+// followed by a closing bracket or brace
+func trailingComma(rest []byte) bool {
+
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		case c == '/' && i+1 < len(rest) && rest[i+1] == '/':
+			for i < len(rest) && rest[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(rest) && rest[i+1] == '*':
+			i += 2
+			for i+1 < len(rest) && !(rest[i] == '*' && rest[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			return true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
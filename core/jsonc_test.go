@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"line comment", "{\"a\": 1 // note\n}", `{"a": 1}`},
+		{"block comment", `{/* note */"a": 1}`, `{"a": 1}`},
+		{"multiline block comment", "{\"a\": /* one\ntwo */ 1}", `{"a": 1}`},
+		{"line comment in a string", `{"a": "// kept"}`, `{"a": "// kept"}`},
+		{"block comment in a string", `{"a": "/* kept */"}`, `{"a": "/* kept */"}`},
+		{"url in a string", `{"host": "https://localhost:8081/" // upstream` + "\n}", `{"host": "https://localhost:8081/"}`},
+		{"escaped quote in a string", `{"a": "say \"//hi\""}`, `{"a": "say \"//hi\""}`},
+		{"trailing comma before brace", `{"a": 1,}`, `{"a": 1}`},
+		{"trailing comma before bracket", `{"a": [1, 2,]}`, `{"a": [1, 2]}`},
+		{"trailing comma then comment", "{\"a\": [1, // last\n]}", `{"a": [1]}`},
+		{"trailing comma then block comment", `{"a": 1, /* end */ }`, `{"a": 1}`},
+		{"comma in a string", `{"a": ",}"}`, `{"a": ",}"}`},
+		{"inner commas kept", `{"a": 1, "b": [1, 2]}`, `{"a": 1, "b": [1, 2]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			out := stripJSONC([]byte(tt.input))
+
+			var got, want any
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatalf("stripJSONC(%q) = %q, not valid json: %v", tt.input, out, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("stripJSONC(%q) = %q, want %s", tt.input, out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripJSONCKeepsLines(t *testing.T) {
+
+	input := "{\n  // one\n  /* two\n  three */\n  \"a\": 1,\n}\n"
+	out := stripJSONC([]byte(input))
+
+	if got, want := bytes.Count(out, []byte("\n")), bytes.Count([]byte(input), []byte("\n")); got != want {
+		t.Errorf("stripJSONC left %d lines, want %d so errors point at the right line:\n%s", got, want, out)
+	}
+}
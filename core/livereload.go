@@ -0,0 +1,177 @@
+package core
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// With liveReload set the reverse proxy streams build results to the browser
+// over server-sent events at /__glr/events, and serves a client script at
+// /__glr/client.js for pages to include. The script reloads the page once a
+// group's run process is back after a reload, and overlays the output of a
+// failed build on the page until the next successful build, like the error
+// overlays of frontend dev servers.
+
+// liveOutputMax bounds the build output kept for the overlay, the end of the
+// output is kept as that's where compilers report the failure
+const liveOutputMax = 32 << 10
+
+// liveMessage is one event sent to the browser
+type liveMessage struct {
+	Type   string `json:"type"` // "build" or "reload"
+	Name   string `json:"name"`
+	OK     bool   `json:"ok,omitzero"`
+	Output string `json:"output,omitzero"`
+}
+
+// newLiveBroker returns a liveBroker with no browsers connected
+func newLiveBroker() *liveBroker {
+	return &liveBroker{
+		clients: make(map[chan []byte]struct{}),
+		builds:  make(map[string][]byte),
+	}
+}
+
+// liveBroker tracks the connected browsers and the last build of each group,
+// sent on connect so a page loaded after a failure shows it
+type liveBroker struct {
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+	builds  map[string][]byte
+}
+
+// publish sends msg to every connected browser, dropping it for one that
+// isn't keeping up rather than blocking a build
+func (lb *liveBroker) publish(msg liveMessage) {
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if msg.Type == "build" {
+		lb.builds[msg.Name] = data
+	}
+	for client := range lb.clients {
+		select {
+		case client <- data:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams messages to one browser until it disconnects
+func (lb *liveBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan []byte, 16)
+	lb.mu.Lock()
+	lb.clients[client] = struct{}{}
+	for _, data := range lb.builds {
+		client <- data
+	}
+	lb.mu.Unlock()
+
+	defer func() {
+		lb.mu.Lock()
+		delete(lb.clients, client)
+		lb.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// keep idle connections from being closed by anything in between
+	ping := time.NewTicker(15 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case data := <-client:
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		flusher.Flush()
+	}
+}
+
+//go:embed static/client.js
+var liveClient []byte
+
+// serveLiveClient serves the live reload client script
+func serveLiveClient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(liveClient)
+}
+
+// liveReload reports whether the reverse proxy serves live reload, builds
+// don't bother broadcasting before then
+func (g *groupState) liveReload() bool {
+	return g != nil && g.liveOn.Load()
+}
+
+// publishBuild tells the browsers how a build ended, with its output when it
+// failed
+func (g *groupState) publishBuild(name string, err error, output []byte) {
+	if !g.liveReload() {
+		return
+	}
+	if err != nil {
+		g.live.publish(liveMessage{Type: "build", Name: name, Output: string(output)})
+		return
+	}
+	g.live.publish(liveMessage{Type: "build", Name: name, OK: true})
+}
+
+// publishReload tells the browsers a group's run process is back
+func (g *groupState) publishReload(name string) {
+	if g.liveReload() {
+		g.live.publish(liveMessage{Type: "reload", Name: name})
+	}
+}
+
+// tailBuffer keeps the last max bytes written to it, safe for the build's
+// stdout and stderr to write at once
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+// Write implements io.Writer
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.max; over > 0 {
+		t.buf = t.buf[over:]
+	}
+
+	return len(p), nil
+}
+
+// Bytes returns the kept output
+func (t *tailBuffer) Bytes() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf
+}
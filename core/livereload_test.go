@@ -0,0 +1,60 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestFailedBuildOverlayOutput(t *testing.T) {
+
+	groups := newGroupState()
+	groups.liveOn.Store(true)
+
+	tests := []struct {
+		name        string
+		parseErrors string
+		output      string
+	}{
+		{"plain", "", "boom\n"},
+		{"parsed nothing recognised", "go", "boom\n"},
+		{"parsed", "go", "main.go:3:1: syntax error: unexpected }\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			b := &Build{
+				Name:        "overlay-" + tt.name,
+				BuildCmd:    os.Args[0],
+				BuildArgs:   []string{"-test.run=^TestHelperProcess$"},
+				BuildEnv:    []string{"GLR_HELPER_OUTPUT=" + tt.output},
+				ParseErrors: tt.parseErrors,
+				Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+				groups:      groups,
+			}
+
+			if err := b.BuildContext(context.Background()); err == nil {
+				t.Fatal("build succeeded, want the helper to fail it")
+			}
+
+			groups.live.mu.Lock()
+			data := groups.live.builds[b.Name]
+			groups.live.mu.Unlock()
+
+			var msg liveMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatalf("no build message published: %v", err)
+			}
+			if msg.OK {
+				t.Error("overlay message reports ok for a failed build")
+			}
+			if msg.Output != tt.output {
+				t.Errorf("overlay output = %q, want %q", msg.Output, tt.output)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BuildLogFile and RunLogFile keep a copy of a group's build and run output
+// on disk. For all-day sessions the files rotate: once a file passes
+// LogMaxSize it is renamed with a timestamp, like app-2025-06-01T15-04-05.log,
+// and only the newest LogMaxBackups rotated files are kept.
+
+// default rotation limits
+const (
+	defaultLogMaxSize    = 10 << 20 // 10MB
+	defaultLogMaxBackups = 3
+)
+
+// logFiles shares one rotatingFile per path, so a build and run logging to
+// the same file and every restart append to the same writer
+var (
+	logFilesMu sync.Mutex
+	logFiles   = make(map[string]*rotatingFile)
+)
+
+// logTo tees stdout and stderr into the log file at path, marking the start
+// of each command with a timestamped header. An empty path returns them as is.
+//
+//	ex: cmd.Stdout, cmd.Stderr = b.logTo(b.RunLogFile, "run", cmd.Stdout, cmd.Stderr)
+func (b *Build) logTo(path, phase string, stdout, stderr io.Writer) (io.Writer, io.Writer) {
+
+	if path == "" {
+		return stdout, stderr
+	}
+
+	logFilesMu.Lock()
+	file, ok := logFiles[path]
+	if !ok {
+		file = &rotatingFile{
+			path:       path,
+			maxSize:    b.LogMaxSize,
+			maxBackups: b.LogMaxBackups,
+			log:        b.log(),
+		}
+		if file.maxSize <= 0 {
+			file.maxSize = defaultLogMaxSize
+		}
+		if file.maxBackups <= 0 {
+			file.maxBackups = defaultLogMaxBackups
+		}
+		logFiles[path] = file
+	}
+	logFilesMu.Unlock()
+
+	fmt.Fprintf(file, "=== %s %s %s ===\n", time.Now().Format(time.RFC3339), phase, b.Name)
+
+	return io.MultiWriter(stdout, file), io.MultiWriter(stderr, file)
+}
+
+// rotatingFile appends to path, rotating it once it grows past maxSize.
+// Writes never fail so a full disk can't break the command writing through
+// it, errors are logged once instead.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	log        *slog.Logger
+
+	file   *os.File
+	size   int64
+	failed bool // an error has been logged, stay quiet until a write works
+}
+
+// Write implements io.Writer
+func (f *rotatingFile) Write(p []byte) (int, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil && f.size+int64(len(p)) > f.maxSize && f.size > 0 {
+		f.rotate()
+	}
+
+	if f.file == nil {
+		err := f.open()
+		if err != nil {
+			f.fail(err)
+			return len(p), nil
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		f.fail(err)
+	} else {
+		f.failed = false
+	}
+
+	return len(p), nil
+}
+
+// open opens path for appending, creating its directory as needed
+func (f *rotatingFile) open() error {
+
+	err := os.MkdirAll(filepath.Dir(f.path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// rotate renames the current file with a timestamp and prunes old backups,
+// the next write opens a fresh file
+func (f *rotatingFile) rotate() {
+
+	f.file.Close()
+	f.file, f.size = nil, 0
+
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext)
+	rotated := base + "-" + time.Now().Format("2006-01-02T15-04-05.000") + ext
+
+	err := os.Rename(f.path, rotated)
+	if err != nil {
+		f.fail(err)
+		return
+	}
+
+	// the timestamps sort oldest first
+	backups, _ := filepath.Glob(base + "-*" + ext)
+	slices.Sort(backups)
+	for len(backups) > f.maxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// fail logs err unless an error was already logged
+func (f *rotatingFile) fail(err error) {
+	if !f.failed {
+		f.log.Warn("log file", "path", f.path, "error", err)
+		f.failed = true
+	}
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MatchedFile pairs a matched file's path with its FileInfo, which only
+// carries the base name
+type MatchedFile struct {
+	Path string
+	fs.FileInfo
+}
+
+// MatchFiles is a function that takes a list of globs and returns the matched
+// files with their paths. Globs starting with "!" remove what they match, the
+// last glob matching a path deciding whether it stays.
+//
+//	ex: files := MatchFiles([]string{"test/*.go", "test/wwwroot/*"})
+func MatchFiles(globs []string) []MatchedFile {
+	return MatchFilesWorkers(globs, 0)
+}
+
+// MatchFilesWorkers is MatchFiles with the file stats spread across a bounded
+// pool of workers. The returned files are sorted by path with any file
+// matched by more than one glob listed once, so the result is stable however
+// the globs are ordered or overlap. A workers value less than one defaults to
+// GOMAXPROCS.
+//
+//	ex: files := MatchFilesWorkers([]string{"**/*.go"}, 8)
+func MatchFilesWorkers(globs []string, workers int) []MatchedFile {
+	return matchFiles(globs, workers, nil)
+}
+
+// matchFileMap is matchFiles keyed by path
+func matchFileMap(globs []string, workers int, cache *dirCache) map[string]fs.FileInfo {
+
+	files := matchFiles(globs, workers, cache)
+
+	matches := make(map[string]fs.FileInfo, len(files))
+	for _, file := range files {
+		matches[file.Path] = file.FileInfo
+	}
+
+	return matches
+}
+
+// matchFiles resolves globs through cache when given, otherwise filepath.Glob,
+// returning the matched files sorted by path
+func matchFiles(globs []string, workers int, cache *dirCache) []MatchedFile {
+
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	paths := []string{}
+
+	log := cache.logger()
+
+	for _, glob := range includes(globs) {
+		matches, err := cache.glob(glob)
+		if err != nil {
+			log.Error("watch", "error", err)
+			continue
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	// overlapping globs can match the same file, sort so the combined
+	// result doesn't depend on glob order
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	// then subtract whatever a "!" glob has the last word on
+	paths = slices.DeleteFunc(paths, func(path string) bool { return negated(globs, path) })
+
+	// each worker fills its own slot so the sorted order is preserved
+	stats := make([]fs.FileInfo, len(paths))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(workers, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				log.Debug("watch", "match", paths[i])
+
+				file, err := os.Stat(paths[i])
+				if err != nil {
+					log.Error("watch", "error", err)
+					continue
+				}
+
+				stats[i] = file
+			}
+		}()
+	}
+
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// drop any files that failed to stat
+	files := []MatchedFile{}
+	for i, file := range stats {
+		if file != nil {
+			files = append(files, MatchedFile{Path: paths[i], FileInfo: file})
+		}
+	}
+
+	return files
+}
+
+// dirsOf stats the unique parent directories of paths, keyed by directory,
+// for watching directory modtimes instead of every file
+func dirsOf(paths []string, log *slog.Logger) map[string]fs.FileInfo {
+
+	dirs := make(map[string]fs.FileInfo)
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if _, ok := dirs[dir]; ok {
+			continue
+		}
+
+		info, err := os.Stat(dir)
+		if err != nil {
+			log.Error("watch", "error", err)
+			continue
+		}
+		dirs[dir] = info
+	}
+
+	return dirs
+}
+
+// racyWindow is how long after a directory's modtime we keep re-reading it,
+// filesystems with coarse timestamps can hide a change within the same tick
+const racyWindow = 2 * time.Second
+
+// dirCache remembers directory listings between scans so an idle tree is not
+// re-enumerated every heartbeat. A directory is only read again when its own
+// modtime changes, which most filesystems bump when entries are added,
+// removed or renamed. Files within are still stat'd by the caller.
+type dirCache struct {
+	log     *slog.Logger
+	entries map[string]dirListing
+}
+
+// logger returns the cache's logger, slog.Default() for a nil cache
+func (c *dirCache) logger() *slog.Logger {
+	if c == nil || c.log == nil {
+		return slog.Default()
+	}
+	return c.log
+}
+
+// dirListing is a cached directory read
+type dirListing struct {
+	modTime time.Time
+	readAt  time.Time
+	names   []string
+}
+
+// glob behaves like filepath.Glob but reads directories through the cache, a
+// nil cache falls back to filepath.Glob
+func (c *dirCache) glob(pattern string) ([]string, error) {
+
+	if c == nil {
+		return filepath.Glob(pattern)
+	}
+
+	// validate the pattern the same way filepath.Glob does
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	if !hasMeta(pattern) {
+		if _, err := os.Lstat(pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	dir, file := filepath.Split(pattern)
+	dir = cleanGlobPath(dir)
+
+	if !hasMeta(dir) {
+		return c.list(dir, file)
+	}
+
+	// prevent infinite recursion on patterns like "[" that end in a separator
+	if dir == pattern {
+		return nil, filepath.ErrBadPattern
+	}
+
+	dirs, err := c.glob(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []string{}
+	for _, d := range dirs {
+		found, err := c.list(d, file)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, found...)
+	}
+
+	return matches, nil
+}
+
+// list returns the entries of dir matching pattern, reading dir only when
+// its modtime has changed since the last read
+func (c *dirCache) list(dir, pattern string) ([]string, error) {
+
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return nil, nil // ignore I/O errors like filepath.Glob
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[string]dirListing)
+	}
+
+	cached, ok := c.entries[dir]
+	if !ok || !cached.modTime.Equal(info.ModTime()) || cached.readAt.Sub(cached.modTime) < racyWindow {
+
+		d, err := os.Open(dir)
+		if err != nil {
+			return nil, nil
+		}
+		names, _ := d.Readdirnames(-1)
+		d.Close()
+		slices.Sort(names)
+
+		c.logger().Debug("watch read dir", "dir", dir, "entries", len(names))
+		cached = dirListing{modTime: info.ModTime(), readAt: time.Now(), names: names}
+		c.entries[dir] = cached
+	}
+
+	matches := []string{}
+	for _, name := range cached.names {
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, filepath.Join(dir, name))
+		}
+	}
+
+	return matches, nil
+}
+
+// hasMeta reports whether path contains any of the magic characters
+// recognized by filepath.Match
+func hasMeta(path string) bool {
+	magicChars := `*?[`
+	if runtime.GOOS != "windows" {
+		magicChars = `*?[\`
+	}
+	return strings.ContainsAny(path, magicChars)
+}
+
+// cleanGlobPath prepares path for glob matching
+func cleanGlobPath(path string) string {
+	switch path {
+	case "":
+		return "."
+	case string(filepath.Separator):
+		return path
+	default:
+		return path[0 : len(path)-1] // chop off trailing separator
+	}
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeTree creates each file, with its directories, under a fresh temp dir
+// and changes into it
+func writeTree(t *testing.T, files ...string) {
+	t.Helper()
+
+	root := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(root)
+}
+
+func TestOverlappingGlobs(t *testing.T) {
+
+	writeTree(t, "main.go", "core/b.go", "core/a.go", "core/notes.txt")
+
+	b := &Build{
+		Name:      "overlap",
+		Match:     []string{"core/*.go", "*.go", "core/a.go"},
+		HeartBeat: Duration(20 * time.Millisecond),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	want := []string{filepath.FromSlash("core/a.go"), filepath.FromSlash("core/b.go"), "main.go"}
+	if got := b.Matches(); !slices.Equal(got, want) {
+		t.Errorf("Matches() = %v, want %v", got, want)
+	}
+
+	var paths []string
+	for _, file := range MatchFiles(b.Match) {
+		paths = append(paths, file.Path)
+	}
+	if !slices.Equal(paths, want) {
+		t.Errorf("MatchFiles() = %v, want %v", paths, want)
+	}
+
+	// the same files seen through several globs must not look changed
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Duration(b.HeartBeat))
+	defer cancel()
+
+	restart := make(chan Change)
+	done := make(chan struct{})
+	go func() {
+		b.Watch(ctx, restart)
+		close(done)
+	}()
+
+	select {
+	case change := <-restart:
+		t.Errorf("spurious change from overlapping globs: %+v", change)
+	case <-done:
+	}
+}
+
+func TestNegatedGlobs(t *testing.T) {
+
+	writeTree(t, "a.go", "a_test.go", "b_test.go", "cmd/main.go", "cmd/gen/x.go")
+
+	globs := []string{"*.go", "!*_test.go", "b_test.go", "cmd/*.go", "cmd/gen/*.go", "!cmd/gen"}
+
+	var got []string
+	for _, file := range MatchFiles(globs) {
+		got = append(got, file.Path)
+	}
+
+	want := []string{"a.go", "b_test.go", filepath.FromSlash("cmd/main.go")}
+	if !slices.Equal(got, want) {
+		t.Errorf("MatchFiles(%v) = %v, want %v", globs, got, want)
+	}
+
+	b := &Build{Name: "negated", Match: globs, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if got := b.Matches(); !slices.Equal(got, want) {
+		t.Errorf("Matches() = %v, want %v", got, want)
+	}
+}
+
+func TestDirCacheInvalidation(t *testing.T) {
+
+	writeTree(t, "src/a.go")
+
+	// well outside racyWindow so the listing can be trusted
+	old := time.Now().Add(-time.Hour)
+	setModTime := func(at time.Time) {
+		t.Helper()
+		if err := os.Chtimes("src", at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := func(cache *dirCache) []string {
+		t.Helper()
+		found, err := cache.glob(filepath.Join("src", "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+
+	setModTime(old)
+	cache := &dirCache{log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if got := list(cache); len(got) != 1 {
+		t.Fatalf("first scan found %q, want src/a.go", got)
+	}
+
+	// an unchanged modtime serves the cached listing
+	os.WriteFile(filepath.Join("src", "b.go"), nil, 0o644)
+	setModTime(old)
+	if got := list(cache); len(got) != 1 {
+		t.Errorf("listing re-read with an unchanged modtime: %q", got)
+	}
+
+	// a new modtime re-reads the directory
+	setModTime(old.Add(time.Minute))
+	if got := list(cache); len(got) != 2 {
+		t.Errorf("listing not re-read after the modtime changed: %q", got)
+	}
+
+	// within racyWindow of the modtime it is re-read even when unchanged
+	recent := time.Now()
+	setModTime(recent)
+	list(cache)
+	os.WriteFile(filepath.Join("src", "c.go"), nil, 0o644)
+	setModTime(recent)
+	if got := list(cache); len(got) != 3 {
+		t.Errorf("listing within racyWindow not re-read: %q", got)
+	}
+
+	// a removed directory drops out rather than serving the old listing
+	os.RemoveAll("src")
+	if got := list(cache); len(got) != 0 {
+		t.Errorf("removed directory still listed: %q", got)
+	}
+}
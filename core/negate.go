@@ -0,0 +1,64 @@
+package core
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// A Match entry starting with "!" excludes what it matches instead of adding
+// to the watch. Entries apply in order and the last one matching a path has
+// the final say, so a later glob can bring back a file an earlier negation
+// dropped. A negation also excludes everything under a directory it matches
+// and applies to Extensions results too. Plain globs behave as before.
+//
+//	ex: ["*.go", "!*_test.go", "cmd/*.go", "!cmd/gen"]
+
+// negation reports whether glob is a "!" entry, returning the pattern without
+// the prefix
+func negation(glob string) (string, bool) {
+	return strings.CutPrefix(glob, "!")
+}
+
+// includes returns the globs that aren't negations, in order
+func includes(globs []string) []string {
+
+	var plain []string
+	for _, glob := range globs {
+		if _, ok := negation(glob); !ok {
+			plain = append(plain, glob)
+		}
+	}
+
+	return plain
+}
+
+// negated reports whether the last entry of globs matching path is a
+// negation. Plain globs match the path itself, negations also match any of
+// its parent directories.
+func negated(globs []string, path string) bool {
+
+	excluded := false
+	for _, glob := range globs {
+		pattern, neg := negation(glob)
+		pattern = filepath.FromSlash(pattern)
+
+		if !neg {
+			if matched, _ := filepath.Match(pattern, path); matched {
+				excluded = false
+			}
+			continue
+		}
+
+		for p := filepath.Clean(path); p != "." && p != string(filepath.Separator); p = filepath.Dir(p) {
+			if matched, _ := filepath.Match(pattern, p); matched {
+				excluded = true
+				break
+			}
+			if filepath.Dir(p) == p {
+				break
+			}
+		}
+	}
+
+	return excluded
+}
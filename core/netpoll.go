@@ -0,0 +1,144 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Event based watchers like fsnotify (inotify, kqueue, ReadDirectoryChangesW)
+// only hear about changes made through the local kernel. Edits on an NFS or
+// SMB server or from another client, and host edits reaching a container
+// through a bind mount on Docker Desktop or a VM's shared folder, raise no
+// events at all, which is why this tool polls. Polling there has its own cost
+// though: every stat is a network round trip, and a tree of thousands of
+// files turns each heartbeat into thousands of them.
+//
+// NetworkPoll trades those per-file stats for reading each watched directory
+// in batches of BatchSize entries, several directories at once. Network
+// clients fetch the attributes of a whole batch in one call (NFS READDIRPLUS,
+// SMB's query directory), so a directory of hundreds of files costs a few
+// round trips rather than hundreds. The results are compared in memory like
+// any other scan.
+
+// NetworkPoll tunes scanning for network filesystems and host mounted volumes
+type NetworkPoll struct {
+
+	// BatchSize is how many entries are read per directory call, default 256
+	BatchSize int `json:"batchSize,omitzero"`
+
+	// Workers is how many directories are read concurrently, default 16 as
+	// network reads wait on latency rather than the CPU
+	Workers int `json:"workers,omitzero"`
+}
+
+// default NetworkPoll tunables
+const (
+	defaultPollBatchSize = 256
+	defaultPollWorkers   = 16
+)
+
+// stat returns the FileInfo of each of paths by reading their directories
+// in batches rather than stat'ing every file. Symlinks are followed with a
+// stat of their own, paths that vanished are left out.
+//
+//	ex: files := b.NetworkPoll.stat(paths, b.log())
+func (p *NetworkPoll) stat(paths []string, log *slog.Logger) map[string]fs.FileInfo {
+
+	batch, workers := p.BatchSize, p.Workers
+	if batch < 1 {
+		batch = defaultPollBatchSize
+	}
+	if workers < 1 {
+		workers = defaultPollWorkers
+	}
+
+	// group the wanted names by directory
+	wanted := make(map[string]map[string]string) // dir => base name => path
+	for _, path := range paths {
+		dir, name := filepath.Split(path)
+		dir = cleanGlobPath(dir)
+		if wanted[dir] == nil {
+			wanted[dir] = make(map[string]string)
+		}
+		wanted[dir][name] = path
+	}
+	dirs := slices.Sorted(maps.Keys(wanted))
+
+	files := make(map[string]fs.FileInfo, len(paths))
+	var mu sync.Mutex
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for range min(workers, len(dirs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for dir := range jobs {
+				found, err := readBatched(dir, wanted[dir], batch)
+				if err != nil {
+					log.Error("watch", "dir", dir, "error", err)
+				}
+				mu.Lock()
+				for path, info := range found {
+					files[path] = info
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, dir := range dirs {
+		jobs <- dir
+	}
+	close(jobs)
+	wg.Wait()
+
+	return files
+}
+
+// readBatched reads dir batch entries at a time, returning the FileInfo of
+// the wanted names keyed by their path
+func readBatched(dir string, wanted map[string]string, batch int) (map[string]fs.FileInfo, error) {
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	found := make(map[string]fs.FileInfo, len(wanted))
+	for len(found) < len(wanted) {
+
+		infos, err := f.Readdir(batch)
+		for _, info := range infos {
+			path, ok := wanted[info.Name()]
+			if !ok {
+				continue
+			}
+			// the listing describes a symlink itself, Watch wants its target
+			if info.Mode()&fs.ModeSymlink != 0 {
+				info, err = os.Stat(path)
+				if err != nil {
+					continue
+				}
+			}
+			found[path] = info
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return found, err
+		}
+	}
+
+	return found, nil
+}
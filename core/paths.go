@@ -0,0 +1,124 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Paths in a config may start with ~ for the user's home directory, like
+// "~/projects/app", which the shell would normally expand. Only a leading "~"
+// or "~/" is expanded, "~user" is left alone. Directory and file paths are
+// also cleaned so "./cmd/../api" and "api" name the same place, commands and
+// globs are only expanded. Remote paths are left for the remote host: with
+// Remote set the build and run directories and commands, of the steps and
+// sidecars too, are sent over ssh as written.
+
+// expandPaths expands and cleans every local path in the config, failing
+// only when a path needs a home directory that can't be found
+func (c *Config) expandPaths() error {
+
+	x := &homeExpander{}
+
+	for i := range c.Builds {
+		b := &c.Builds[i]
+
+		x.file(&b.WatchDir)
+		x.file(&b.StateFile)
+		x.file(&b.EnvFile)
+		x.file(&b.OutDir)
+		x.file(&b.BuildLogFile)
+		x.file(&b.RunLogFile)
+		x.file(&b.TriggerFile)
+		x.each(b.Match)
+		x.each(b.Ignore)
+
+		if b.Container != nil {
+			x.each(b.Container.Mounts)
+		}
+		if b.Remote != nil {
+			x.file(&b.Remote.Key)
+			continue
+		}
+
+		x.file(&b.BuildDir)
+		x.file(&b.RunDir)
+		x.expand(&b.BuildCmd)
+		x.expand(&b.RunCmd)
+
+		for j := range b.Steps {
+			x.file(&b.Steps[j].BuildDir)
+			x.expand(&b.Steps[j].BuildCmd)
+		}
+		for j := range b.Sidecars {
+			x.file(&b.Sidecars[j].RunDir)
+			x.expand(&b.Sidecars[j].RunCmd)
+		}
+	}
+
+	for i := range c.SharedMatch {
+		x.each(c.SharedMatch[i].Match)
+	}
+
+	x.file(&c.TLSCertFile)
+	x.file(&c.TLSKeyFile)
+
+	if s := c.StaticServer; s != nil {
+		x.file(&s.StaticDir)
+		for prefix, dir := range s.StaticMounts {
+			x.file(&dir)
+			s.StaticMounts[prefix] = dir
+		}
+	}
+
+	return x.err
+}
+
+// homeExpander looks up the home directory the first time a path needs it
+type homeExpander struct {
+	home string
+	err  error
+}
+
+// expand replaces a leading ~ in *path with the home directory
+func (x *homeExpander) expand(path *string) {
+
+	rest, ok := strings.CutPrefix(*path, "~")
+	if !ok || (rest != "" && !os.IsPathSeparator(rest[0]) && rest[0] != '/') {
+		return
+	}
+
+	if x.home == "" && x.err == nil {
+		x.home, x.err = os.UserHomeDir()
+		if x.err != nil {
+			x.err = fmt.Errorf("expanding %q: %w", *path, x.err)
+		}
+	}
+	if x.err != nil {
+		return
+	}
+
+	*path = x.home + rest
+}
+
+// file expands and cleans a directory or file path, empty stays empty
+func (x *homeExpander) file(path *string) {
+	if *path == "" {
+		return
+	}
+	x.expand(path)
+	*path = filepath.ToSlash(filepath.Clean(filepath.FromSlash(*path)))
+}
+
+// each expands every path in paths, after the "!" of a negated glob
+func (x *homeExpander) each(paths []string) {
+	for i := range paths {
+		pattern, neg := negation(paths[i])
+		x.expand(&pattern)
+		if neg {
+			pattern = "!" + pattern
+		}
+		paths[i] = pattern
+	}
+}
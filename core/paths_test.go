@@ -0,0 +1,70 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandPathsLeavesRemoteGroups(t *testing.T) {
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	c := &Config{Builds: []Build{
+		{
+			Name:     "local",
+			BuildDir: "~/app",
+			RunDir:   "~/app/./bin",
+			BuildCmd: "~/bin/go",
+			Match:    []string{"~/app/*.go"},
+		},
+		{
+			Name:     "remote",
+			BuildDir: "~/app",
+			RunDir:   "~/app/./bin",
+			BuildCmd: "~/bin/go",
+			RunCmd:   "~/app/bin/server",
+			Match:    []string{"~/app/*.go"},
+			Steps:    []BuildStep{{Name: "css", BuildDir: "~/app/css", BuildCmd: "~/bin/sass"}},
+			Sidecars: []Sidecar{{Name: "db", RunDir: "~/db", RunCmd: "~/bin/db"}},
+			Remote:   &Remote{Host: "pi.local", Key: "~/.ssh/id_ed25519"},
+		},
+	}}
+
+	if err := c.expandPaths(); err != nil {
+		t.Fatal(err)
+	}
+
+	local := c.Builds[0]
+	if want := filepath.ToSlash(home) + "/app"; local.BuildDir != want {
+		t.Errorf("local buildDir = %q, want %q", local.BuildDir, want)
+	}
+	if want := home + "/bin/go"; local.BuildCmd != want {
+		t.Errorf("local buildCmd = %q, want %q", local.BuildCmd, want)
+	}
+
+	remote := c.Builds[1]
+	for field, got := range map[string]string{
+		"buildDir":           remote.BuildDir,
+		"runDir":             remote.RunDir,
+		"buildCmd":           remote.BuildCmd,
+		"runCmd":             remote.RunCmd,
+		"steps[0].buildDir":  remote.Steps[0].BuildDir,
+		"steps[0].buildCmd":  remote.Steps[0].BuildCmd,
+		"sidecars[0].runDir": remote.Sidecars[0].RunDir,
+		"sidecars[0].runCmd": remote.Sidecars[0].RunCmd,
+	} {
+		if len(got) == 0 || got[0] != '~' {
+			t.Errorf("remote %s = %q, want it left for the remote host", field, got)
+		}
+	}
+
+	// the watched globs and the ssh key are still local
+	if want := home + "/app/*.go"; remote.Match[0] != want {
+		t.Errorf("remote match = %q, want %q", remote.Match[0], want)
+	}
+	if want := filepath.ToSlash(home) + "/.ssh/id_ed25519"; remote.Remote.Key != want {
+		t.Errorf("remote key = %q, want %q", remote.Remote.Key, want)
+	}
+}
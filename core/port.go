@@ -0,0 +1,38 @@
+package core
+
+import (
+	"net"
+)
+
+// AutoPort as a group's Port picks a free port when the group starts, so
+// many groups can run side by side without agreeing on ports up front. The
+// port is kept for the rest of the session, replaces {port} like any other
+// and reverse proxy targets naming the group in buildGroup follow it.
+const AutoPort = "auto"
+
+// resolvePort replaces an AutoPort with a port the OS reports free by
+// binding :0, the listener is closed again so the run command can take it
+func (b *Build) resolvePort() {
+
+	if b.Port != AutoPort {
+		return
+	}
+
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		b.log().Error("port auto-select", "name", b.Name, "error", err)
+		b.Port = ""
+		return
+	}
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+	if err != nil {
+		b.log().Error("port auto-select", "name", b.Name, "error", err)
+		b.Port = ""
+		return
+	}
+
+	b.Port = port
+	b.groups.setActivePort(b.Name, port)
+	b.log().Info("port auto-selected", "name", b.Name, "port", port)
+}
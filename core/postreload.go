@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"os/exec"
+)
+
+// PostReload is a command run each time the run process is launched, once
+// ReadyCheck answers if set, like opening or refreshing a browser tab or a
+// screenshot test. It runs in the background with its output streamed like
+// the run command's and is cancelled when the next reload replaces the run
+// process it was started for.
+
+// postReload tells live reload browsers and starts PostReload in the
+// background for the run process of ctx. With wait set it first waits for
+// ReadyCheck, a blue/green swap has already done so.
+func (b *Build) postReload(ctx context.Context, wait bool) {
+
+	if len(b.PostReload) == 0 && !b.groups.liveReload() {
+		return
+	}
+
+	port := b.Port
+	if active, ok := b.groups.activePort(b.Name); ok {
+		port = active
+	}
+	args := expandPort(b.PostReload, port)
+
+	go func() {
+
+		if wait && b.ReadyCheck != "" {
+			err := b.waitReady(ctx, "")
+			if err != nil {
+				b.log().Warn("post-reload skipped, run process not ready", "name", b.Name, "error", err)
+				return
+			}
+		}
+
+		b.groups.publishReload(b.Name)
+		if len(b.PostReload) == 0 {
+			return
+		}
+
+		b.log().Info("post-reload execute", "name", b.Name, "postReload", args)
+
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stdout, cmd.Stderr = b.outputs()
+
+		err := cmd.Run()
+		switch {
+		case ctx.Err() != nil:
+			b.log().Debug("post-reload cancelled by the next reload", "name", b.Name)
+		case err != nil:
+			b.log().Error("post-reload", "name", b.Name, "error", err)
+		default:
+			b.log().Info("post-reload success", "name", b.Name)
+		}
+	}()
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"bytes"
+	"hash/fnv"
+	"io"
+	"os"
+	"sync"
+)
+
+// With several build groups writing to the same terminal it is hard to tell
+// whose output is whose. A group with PrefixOutput, Label or Color set has
+// every line of its build and run output prefixed with its label, colored
+// when stdout is a terminal.
+
+// colors are the ANSI foreground colors selectable with a build group's
+// Color field, the hashed fallback picks from the same set
+var colors = map[string]string{
+	"red":     "\x1b[31m",
+	"green":   "\x1b[32m",
+	"yellow":  "\x1b[33m",
+	"blue":    "\x1b[34m",
+	"magenta": "\x1b[35m",
+	"cyan":    "\x1b[36m",
+}
+
+// palette orders colors for the hashed fallback so a name always gets the
+// same color
+var palette = []string{"cyan", "green", "yellow", "magenta", "blue", "red"}
+
+// colorReset ends a colored prefix
+const colorReset = "\x1b[0m"
+
+// outputMu keeps lines from different groups from interleaving mid-line
+var outputMu sync.Mutex
+
+// useColor reports whether stdout is a terminal and NO_COLOR is unset
+var useColor = sync.OnceValue(func() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+})
+
+// outputs returns the writers for the group's subprocess stdout and stderr
+//
+//	ex: cmd.Stdout, cmd.Stderr = b.outputs()
+func (b *Build) outputs() (io.Writer, io.Writer) {
+
+	// sidecars share the terminal, so their group is prefixed too
+	if !b.PrefixOutput && b.Label == "" && b.Color == "" && len(b.Sidecars) == 0 {
+		return os.Stdout, os.Stderr
+	}
+
+	prefix := b.outputPrefix()
+	return &prefixWriter{out: os.Stdout, prefix: prefix, lineStart: true},
+		&prefixWriter{out: os.Stderr, prefix: prefix, lineStart: true}
+}
+
+// outputPrefix renders the label, defaulting to the group name, in the
+// configured or hashed color
+func (b *Build) outputPrefix() []byte {
+
+	label := b.Label
+	if label == "" {
+		label = b.Name
+	}
+
+	if !useColor() {
+		return []byte(label + " | ")
+	}
+
+	color, ok := colors[b.Color]
+	if !ok {
+		if b.Color != "" {
+			b.log().Warn("unknown color, using a hashed one", "name", b.Name, "color", b.Color)
+		}
+		h := fnv.New32a()
+		h.Write([]byte(label))
+		color = colors[palette[h.Sum32()%uint32(len(palette))]]
+	}
+
+	return []byte(color + label + colorReset + " | ")
+}
+
+// prefixWriter writes prefix at the start of every line. Partial lines are
+// passed through immediately so prompts still show up.
+type prefixWriter struct {
+	out       io.Writer
+	prefix    []byte
+	lineStart bool
+}
+
+// Write implements io.Writer
+func (w *prefixWriter) Write(p []byte) (int, error) {
+
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	var buf bytes.Buffer
+	for line := range bytes.Lines(p) {
+		if w.lineStart {
+			buf.Write(w.prefix)
+		}
+		buf.Write(line)
+		w.lineStart = line[len(line)-1] == '\n'
+	}
+
+	_, err := w.out.Write(buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
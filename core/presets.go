@@ -0,0 +1,125 @@
+package core
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// Presets maps a --preset name to a function returning a starter Config
+// tailored to that stack
+var Presets = map[string]func() *Config{
+	"go":     NewConfig,
+	"templ":  NewTemplConfig,
+	"node":   NewNodeConfig,
+	"static": NewStaticConfig,
+}
+
+// PresetNames returns the sorted names of all presets
+//
+//	ex: names := PresetNames() // [go node static templ]
+func PresetNames() []string {
+	return slices.Sorted(maps.Keys(Presets))
+}
+
+// NewTemplConfig returns a Config for a Go webserver using templ components.
+//
+// The templ group regenerates *_templ.go files which in turn trigger the
+// webserver group to rebuild and rerun.
+func NewTemplConfig() *Config {
+
+	c := &Config{
+		Name:         "github.com/dearing/webserver",
+		Description:  "sample templ webserver config",
+		Bind:         ":8080",
+		ReverseProxy: make(map[string]HttpTarget),
+
+		Builds: []Build{
+			{
+				Name:        "templ",
+				Description: "generate templ components",
+				Match:       []string{"*.templ", "components/*.templ"},
+				HeartBeat:   Duration(1 * time.Second),
+				BuildCmd:    "go",
+				BuildArgs:   []string{"tool", "templ", "generate"},
+				BuildDir:    ".",
+			},
+			{
+				Name:        "webserver",
+				Description: "sample webserver",
+				Match:       []string{"*.go", "components/*.go"},
+				HeartBeat:   Duration(1 * time.Second),
+				BuildCmd:    "go",
+				BuildArgs:   []string{"build", "-o", "build/"},
+				BuildEnv:    []string{"CGO_ENABLED=0"},
+				BuildDir:    ".",
+				RunCmd:      "./webserver",
+				RunArgs:     []string{"--www-bind", ":{port}"},
+				RunDir:      "build",
+				Port:        "8081",
+			},
+		},
+	}
+
+	c.ReverseProxy["/"] = HttpTarget{
+		Host: "http://localhost:8081",
+	}
+
+	return c
+}
+
+// NewNodeConfig returns a Config for a node project driven by npm scripts
+func NewNodeConfig() *Config {
+
+	c := &Config{
+		Name:         "webapp",
+		Description:  "sample node config",
+		Bind:         ":8080",
+		ReverseProxy: make(map[string]HttpTarget),
+
+		Builds: []Build{
+			{
+				Name:        "webapp",
+				Description: "npm build and start",
+				Match:       []string{"package.json", "src/*.js", "src/*.ts", "src/*/*.js", "src/*/*.ts"},
+				HeartBeat:   Duration(1 * time.Second),
+				BuildCmd:    "npm",
+				BuildArgs:   []string{"run", "build"},
+				BuildDir:    ".",
+				RunCmd:      "npm",
+				RunArgs:     []string{"start"},
+				RunDir:      ".",
+				Port:        "8081",
+			},
+		},
+	}
+
+	c.ReverseProxy["/"] = HttpTarget{
+		Host: "http://localhost:8081",
+	}
+
+	return c
+}
+
+// NewStaticConfig returns a Config serving a directory of static files,
+// restarting the file server when anything in wwwroot changes
+func NewStaticConfig() *Config {
+
+	return &Config{
+		Name:        "website",
+		Description: "sample static site config",
+
+		Builds: []Build{
+			{
+				Name:        "wwwroot",
+				Description: "serve wwwroot",
+				Match:       []string{"wwwroot/*", "wwwroot/*/*"},
+				HeartBeat:   Duration(1 * time.Second),
+				RunCmd:      "go",
+				RunArgs:     []string{"run", "github.com/dearing/webserver@latest", "--www-bind", ":{port}", "--www-root", "wwwroot"},
+				RunDir:      ".",
+				Port:        "8081",
+			},
+		},
+	}
+}
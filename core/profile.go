@@ -0,0 +1,116 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Profiles keep variations of a config in one file, like a test profile that
+// runs the tests as a one-shot or a prod-like profile with the race detector
+// off. A profile is written like a config and only holds what differs: its
+// fields replace the base config's, objects like staticServer merge field by
+// field and lists replace. Builds are matched by name, only the fields given
+// change, and a name the base doesn't have adds a build group.
+//
+//	ex: {"profiles": {"test": {"builds": [{"name": "api", "runCmd": "go", "runArgs": ["test", "./..."], "oneShot": true}]}}}
+
+// ApplyProfile merges the named profile over the config
+//
+//	ex: err := config.ApplyProfile("test")
+func (c *Config) ApplyProfile(name string) error {
+
+	raw, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q, the config has %v", name, slices.Sorted(maps.Keys(c.Profiles)))
+	}
+
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(raw, &fields)
+	if err != nil {
+		return fmt.Errorf("profile %q: %w", name, err)
+	}
+
+	// heartbeats taken from DefaultHeartBeat are resolved again once merged
+	for i := range c.Builds {
+		if c.Builds[i].defaultBeat {
+			c.Builds[i].HeartBeat = 0
+			c.Builds[i].defaultBeat = false
+		}
+	}
+
+	// builds merge by name below, profiles can't nest
+	builds := fields["builds"]
+	delete(fields, "builds")
+	delete(fields, "profiles")
+
+	rest, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("profile %q: %w", name, err)
+	}
+	err = json.Unmarshal(rest, c)
+	if err != nil {
+		return fmt.Errorf("profile %q: %w", name, err)
+	}
+
+	if builds != nil {
+		var overrides []json.RawMessage
+		err = json.Unmarshal(builds, &overrides)
+		if err != nil {
+			return fmt.Errorf("profile %q builds: %w", name, err)
+		}
+
+		for i, override := range overrides {
+			err = c.mergeBuild(override)
+			if err != nil {
+				return fmt.Errorf("profile %q builds[%d]: %w", name, i, err)
+			}
+		}
+	}
+
+	err = c.checkAuth()
+	if err != nil {
+		return fmt.Errorf("profile %q: %w", name, err)
+	}
+
+	err = c.expandPaths()
+	if err != nil {
+		return err
+	}
+
+	c.applyDefaults()
+	c.attach()
+
+	return c.ResolveToolchains()
+}
+
+// mergeBuild decodes override over the build group of the same name, or adds
+// it as a new group
+func (c *Config) mergeBuild(override json.RawMessage) error {
+
+	var named struct {
+		Name string `json:"name"`
+	}
+	err := json.Unmarshal(override, &named)
+	if err != nil {
+		return err
+	}
+	if named.Name == "" {
+		return fmt.Errorf("name is required to match a build group")
+	}
+
+	for i := range c.Builds {
+		if c.Builds[i].Name == named.Name {
+			return json.Unmarshal(override, &c.Builds[i])
+		}
+	}
+
+	build, err := c.newBuild(override)
+	if err != nil {
+		return err
+	}
+	c.Builds = append(c.Builds, build)
+
+	return nil
+}
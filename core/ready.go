@@ -0,0 +1,79 @@
+package core
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// At startup the reverse proxy can come up before a build group has built
+// and bound its port, answering the first requests with a 502. A proxy target
+// following a build group with a ReadyCheck holds its requests until that
+// group's first run is ready, or readyTimeout passes.
+
+// readyGate is closed once a build group first passes its ReadyCheck
+type readyGate struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+// gate returns the readyGate of a build group, creating it if needed
+func (g *groupState) gate(name string) *readyGate {
+	gate, _ := g.gates.LoadOrStore(name, &readyGate{ch: make(chan struct{})})
+	return gate.(*readyGate)
+}
+
+// markReady opens the build group's readyGate, repeated calls are a no-op
+func (g *groupState) markReady(name string) {
+	if g == nil {
+		return
+	}
+	gate := g.gate(name)
+	gate.once.Do(func() { close(gate.ch) })
+}
+
+// isReady reports whether the build group has passed its ReadyCheck
+func (g *groupState) isReady(name string) bool {
+	if g == nil {
+		return false
+	}
+	select {
+	case <-g.gate(name).ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitUpstream holds requests to next until the build group is ready. After
+// readyTimeout requests are let through regardless so a broken ReadyCheck
+// can't wedge the proxy.
+//
+//	ex: mux.Handle(path, groups.waitUpstream(log, path, "webserver", proxy))
+func (g *groupState) waitUpstream(log *slog.Logger, path, group string, next http.Handler) http.Handler {
+
+	open := make(chan struct{})
+
+	go func() {
+		defer close(open)
+
+		log.Info("reverse-proxy waiting on upstream", "path", path, "build-group", group)
+
+		select {
+		case <-g.gate(group).ch:
+			log.Info("reverse-proxy upstream ready", "path", path, "build-group", group)
+		case <-time.After(readyTimeout):
+			log.Warn("reverse-proxy upstream not ready, proxying anyway", "path", path, "build-group", group, "timeout", readyTimeout)
+		}
+	}()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-open:
+		case <-r.Context().Done():
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
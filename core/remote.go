@@ -0,0 +1,92 @@
+package core
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Remote runs a build group's build and run commands on another host over
+// ssh, for when the target differs from the workstation. Watching stays
+// local, so the sources need to reach the remote another way, like a shared
+// mount or a build step that syncs them. Output is streamed back.
+//
+//	ex: {"host": "pi.local", "user": "dev", "dir": "/home/dev/app"}
+type Remote struct {
+
+	// Host to connect to
+	// ex: "pi.local"
+	Host string `json:"host"`
+
+	// User and Port default to whatever ssh would pick
+	User string `json:"user,omitzero"`
+	Port int    `json:"port,omitzero"`
+
+	// Key is an identity file passed with -i
+	// ex: "~/.ssh/id_ed25519"
+	Key string `json:"key,omitzero"`
+
+	// Dir is the remote working directory, BuildDir and RunDir are resolved
+	// beneath it
+	// ex: "/home/dev/app"
+	Dir string `json:"dir,omitzero"`
+
+	// Args are extra ssh arguments placed before the host
+	// ex: ["-o", "ConnectTimeout=5"]
+	Args []string `json:"args,omitzero"`
+}
+
+// command wraps name and args in an ssh invocation, returning "ssh" and its
+// arguments. The run command gets a terminal so stopping ssh hangs up the
+// remote process instead of leaving it running.
+//
+//	ex: name, args, err := r.command("go", []string{"build"}, "cmd/api", env, false)
+func (r *Remote) command(name string, args []string, dir string, env []string, tty bool) (string, []string, error) {
+
+	if r.Host == "" {
+		return "", nil, errors.New("remote host not defined")
+	}
+
+	ssh := []string{}
+	if tty {
+		ssh = append(ssh, "-tt")
+	}
+	if r.Key != "" {
+		ssh = append(ssh, "-i", r.Key)
+	}
+	if r.Port != 0 {
+		ssh = append(ssh, "-p", strconv.Itoa(r.Port))
+	}
+	ssh = append(ssh, r.Args...)
+
+	host := r.Host
+	if r.User != "" {
+		host = r.User + "@" + host
+	}
+
+	// exec replaces the remote shell so the hang up reaches the command
+	script := []string{}
+	if workdir := path.Join(r.Dir, filepath.ToSlash(dir)); workdir != "" {
+		script = append(script, "cd", shellQuote(workdir), "&&")
+	}
+	script = append(script, "exec")
+	if len(env) > 0 {
+		script = append(script, "env")
+		for _, kv := range env {
+			script = append(script, shellQuote(kv))
+		}
+	}
+	for _, arg := range slices.Concat([]string{name}, args) {
+		script = append(script, shellQuote(arg))
+	}
+
+	return "ssh", append(ssh, host, strings.Join(script, " ")), nil
+}
+
+// shellQuote single quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// When the static server shares the reverse proxy's listener, router decides
+// per request whether the static site or a proxy route answers. By default
+// proxy routes win, then static files, then the FallbackProxy route if set,
+// and finally the static site's 404. The FallbackProxy route only answers
+// misses, so it can be "/" without hiding the static site. With Precedence
+// "static" an existing static file wins over a proxy route.
+
+// router serves the static site and the proxy routes from one origin
+type router struct {
+	proxies     *http.ServeMux // the reverse proxy routes only
+	site        *staticSite
+	static      http.Handler // site wrapped with the static server's headers and auth
+	fallback    http.Handler // route for static misses, nil for a 404
+	fallbackFor string       // the fallback's own path, only used for misses
+	staticFirst bool
+	log         *slog.Logger
+}
+
+// router builds the combined handler for RunProxy, the returned func closes
+// the static site's directories
+func (c *Config) router(proxies *http.ServeMux, routes map[string]http.Handler, log *slog.Logger) (http.Handler, func()) {
+
+	s := c.StaticServer
+
+	site := s.site(log)
+	if site.served == 0 {
+		log.Error("static-server", "error", "nothing to serve")
+	}
+
+	r := &router{
+		proxies:     proxies,
+		site:        site,
+		static:      s.BasicAuth.wrap(withHeaders(s.Headers, site), log),
+		staticFirst: s.Precedence == "static",
+		log:         log,
+	}
+
+	if s.FallbackProxy != "" {
+		fallback, ok := routes[s.FallbackProxy]
+		if !ok {
+			log.Error("static-server", "error", "fallbackProxy is not a reverseProxy path", "fallbackProxy", s.FallbackProxy)
+		}
+		r.fallback = fallback
+		r.fallbackFor = s.FallbackProxy
+	}
+
+	switch s.Precedence {
+	case "", "proxy", "static":
+	default:
+		log.Warn("static-server unknown precedence, proxy routes win", "precedence", s.Precedence)
+	}
+
+	return r, site.Close
+}
+
+// ServeHTTP implements http.Handler
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	_, route := rt.proxies.Handler(r)
+	exists := rt.site.exists(r.URL.Path)
+
+	switch {
+	case rt.staticFirst && exists:
+		rt.log.Debug("route static", "path", r.URL.Path, "reason", "static file first")
+		rt.static.ServeHTTP(w, r)
+	case route != "" && route != rt.fallbackFor:
+		rt.log.Debug("route proxy", "path", r.URL.Path, "route", route)
+		rt.proxies.ServeHTTP(w, r)
+	case exists:
+		rt.log.Debug("route static", "path", r.URL.Path)
+		rt.static.ServeHTTP(w, r)
+	case rt.fallback != nil:
+		rt.log.Debug("route fallback proxy", "path", r.URL.Path)
+		rt.fallback.ServeHTTP(w, r)
+	default:
+		rt.log.Debug("route static", "path", r.URL.Path, "reason", "no match")
+		rt.static.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Options configures Run for programs embedding the reloader
+type Options struct {
+
+	// Builds are the build groups to run
+	Builds []Build
+
+	// ShutdownOrder lists build groups to stop first on exit, the rest stop
+	// in reverse order
+	ShutdownOrder []string
+
+	// SharedMatch fans changes to shared globs out to several build groups
+	SharedMatch []SharedMatch
+
+	// Strict treats warnings like a match overlapping the build output as
+	// errors
+	Strict bool
+
+	// ControlSocket listens for reload, stop, start and status commands on
+	// this unix socket when set
+	ControlSocket string
+
+	// Logger is given to every build group that doesn't set its own,
+	// defaulting to slog.Default()
+	Logger *slog.Logger
+}
+
+// Run checks and starts every build group in opts, blocking until ctx is
+// cancelled and the groups have shut down
+//
+//	ex: err := Run(ctx, Options{Builds: config.Builds})
+func Run(ctx context.Context, opts Options) error {
+
+	if len(opts.Builds) == 0 {
+		return errors.New("no build groups defined")
+	}
+
+	for i := range opts.Builds {
+		if opts.Builds[i].Logger == nil {
+			opts.Builds[i].Logger = opts.Logger
+		}
+	}
+
+	err := CheckBuilds(opts.Builds, opts.Strict)
+	if err != nil {
+		return err
+	}
+
+	// the groups get their own context so Shutdown can stop them in order
+	// after ctx is cancelled
+	groupCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	supervisor := NewSupervisor(groupCtx, opts.Builds)
+	supervisor.Logger = opts.Logger
+	supervisor.Start()
+	supervisor.WatchShared(opts.SharedMatch)
+
+	if opts.ControlSocket != "" {
+		go supervisor.ServeControl(groupCtx, opts.ControlSocket)
+	}
+
+	<-ctx.Done()
+	supervisor.log().Info("run stopping", "build-groups", len(opts.Builds))
+	supervisor.Shutdown(opts.ShutdownOrder)
+
+	return nil
+}
+
+// CheckBuilds validates build groups before they start: only one may pass
+// stdin through and a match overlapping the build output is excluded from
+// the watch, or an error when strict is set
+//
+//	ex: err := CheckBuilds(selected, false)
+func CheckBuilds(builds []Build, strict bool) error {
+
+	err := checkNames(builds)
+	if err != nil {
+		return err
+	}
+
+	// stdin can only be passed through to a single build group
+	var stdinGroups []string
+	for _, build := range builds {
+		if build.Stdin {
+			stdinGroups = append(stdinGroups, build.Name)
+		}
+	}
+	if len(stdinGroups) > 1 {
+		return fmt.Errorf("only one build group may set stdin: %v", stdinGroups)
+	}
+
+	for i := range builds {
+		if err := builds[i].checkCompare(); err != nil {
+			return err
+		}
+		if err := builds[i].checkReload(); err != nil {
+			return err
+		}
+		if builds[i].NoBuild && builds[i].NoRun {
+			return fmt.Errorf("build group %q sets both noBuild and noRun, leaving nothing to do", builds[i].Name)
+		}
+		builds[i].checkRunCmd()
+	}
+
+	// catch build groups that would restart themselves on their own output
+	for i := range builds {
+		err := builds[i].CheckFeedback(strict)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to share between logging goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunLogsToOptionsLogger(t *testing.T) {
+
+	var out syncBuffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	// capture anything that still reaches the default logger
+	var stray syncBuffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&stray, nil)))
+	defer slog.SetDefault(previous)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	err := Run(ctx, Options{
+		Builds: []Build{{
+			Name:      "quiet",
+			BuildDir:  t.TempDir(),
+			BuildCmd:  "go",
+			BuildArgs: []string{"env", "GOOS"},
+			NoRun:     true,
+			HeartBeat: Duration(50 * time.Millisecond),
+		}},
+		Logger: logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"watch start", "run stopping", "shutdown sequence", "shutdown complete"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Options.Logger is missing %q:\n%s", want, out.String())
+		}
+	}
+	if stray.String() != "" {
+		t.Errorf("output reached slog.Default():\n%s", stray.String())
+	}
+}
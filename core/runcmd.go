@@ -0,0 +1,49 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// On unix a bare RunCmd like "webserver" is looked up in $PATH only, never in
+// RunDir, so a freshly built binary fails with "executable file not found in
+// $PATH" until it's written as "./webserver", see the notes in NewConfig.
+
+// checkRunCmd warns when RunCmd is a bare name that isn't in $PATH but names
+// a file in RunDir, or the build's output, suggesting the ./ form instead
+//
+//	ex: b.checkRunCmd()
+func (b *Build) checkRunCmd() {
+
+	if runtime.GOOS == "windows" || b.NoRun || b.Container != nil || b.Remote != nil {
+		return
+	}
+
+	if b.RunCmd == "" || strings.ContainsRune(b.RunCmd, '/') {
+		return
+	}
+
+	if _, err := exec.LookPath(b.RunCmd); err == nil {
+		return
+	}
+
+	local := filepath.Join(filepath.FromSlash(b.RunDir), b.RunCmd)
+
+	found := false
+	if info, err := os.Stat(local); err == nil && !info.IsDir() {
+		found = true
+	} else if out, isDir, ok := b.outputPath(); ok {
+		// the build may not have written it yet
+		if isDir {
+			out = filepath.Join(out, b.RunCmd)
+		}
+		found = filepath.Clean(out) == filepath.Clean(local)
+	}
+
+	if found {
+		b.log().Warn("runCmd is not in $PATH and will not be found in runDir without ./", "name", b.Name, "runCmd", b.RunCmd, "runDir", b.RunDir, "suggest", "./"+b.RunCmd)
+	}
+}
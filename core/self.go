@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// WatchExecutable sends the path of the running executable on the returned
+// channel once it has been replaced, like by a go install while working on
+// the tool itself. A change is only reported after the file has stayed the
+// same for a heartBeat so a binary still being written isn't picked up. A nil
+// log defaults to slog.Default().
+//
+//	ex: replaced, err := core.WatchExecutable(ctx, time.Second, logger)
+func WatchExecutable(ctx context.Context, heartBeat time.Duration, log *slog.Logger) (<-chan string, error) {
+
+	if log == nil {
+		log = slog.Default()
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	replaced := make(chan string, 1)
+
+	go func() {
+		tick := time.NewTicker(heartBeat)
+		defer tick.Stop()
+
+		var previous os.FileInfo // the last stat that differed from original
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				log.Debug("self-reload", "path", path, "error", err)
+				previous = nil
+				continue
+			}
+
+			if sameFile(info, original) {
+				previous = nil
+				continue
+			}
+
+			// wait for one quiet heartbeat before reporting
+			if previous == nil || !sameFile(info, previous) {
+				previous = info
+				continue
+			}
+
+			replaced <- path
+			return
+		}
+	}()
+
+	return replaced, nil
+}
+
+// sameFile reports whether two stats of a path look like the same file
+func sameFile(a, b os.FileInfo) bool {
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"time"
+)
+
+// A build often leaves churn behind, like generated code, temp files or an
+// output the globs can see, and the next scan reloads the group a second
+// time for nothing. SettleWindow gives Watch a short window after each build
+// where it takes whatever it sees as the new baseline instead of restarting.
+// A file saved within the window is missed until it changes again.
+
+// markBuilt opens the settle window after a build or step finishes, the time
+// is kept in the group's state as Start and Watch each run their own loop
+func (b *Build) markBuilt() {
+	if b.SettleWindow > 0 && b.groups != nil {
+		b.groups.built.Store(b.Name, time.Now())
+	}
+}
+
+// settling reports whether now falls within the settle window of the last
+// build
+func (b *Build) settling(now time.Time) bool {
+
+	if b.SettleWindow <= 0 || b.groups == nil {
+		return false
+	}
+
+	at, ok := b.groups.built.Load(b.Name)
+	if !ok {
+		return false
+	}
+
+	return now.Sub(at.(time.Time)) < time.Duration(b.SettleWindow)
+}
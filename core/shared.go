@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"slices"
+)
+
+// SharedMatch watches globs shared by several build groups, like a common
+// package in a monorepo. A change fans out to every group named in Groups
+// while changes to a group's own Match still restart only that group.
+//
+//	ex: {"name": "lib", "match": ["lib/*.go"], "groups": ["api", "worker"]}
+type SharedMatch struct {
+	Name      string   `json:"name,omitzero"`
+	Match     []string `json:"match"`
+	Groups    []string `json:"groups"`
+	HeartBeat Duration `json:"heartBeat,omitzero"`
+}
+
+// WatchShared starts a watcher for each SharedMatch that runs until the
+// supervisor's context is cancelled. Groups that aren't supervised, for
+// example when left out by --build-groups, are skipped with a warning.
+//
+//	ex: s.WatchShared(config.SharedMatch)
+func (s *Supervisor) WatchShared(shared []SharedMatch) {
+
+	for _, sm := range shared {
+
+		groups := []string{}
+		for _, name := range sm.Groups {
+			if _, ok := s.groups[name]; !ok {
+				s.log().Warn("shared match skipping unknown build group", "shared", sm.Name, "build-group", name)
+				continue
+			}
+			groups = append(groups, name)
+		}
+
+		if len(groups) == 0 {
+			continue
+		}
+
+		// reuse Build for the scanning, diffing and logging
+		watcher := &Build{
+			Name:      "shared/" + sm.Name,
+			Match:     sm.Match,
+			HeartBeat: sm.HeartBeat,
+			Logger:    s.Logger,
+			groups:    s.shared,
+		}
+
+		changes := make(chan Change)
+		go watcher.Watch(s.ctx, changes)
+		go func() {
+			for {
+				select {
+				case <-s.ctx.Done():
+					return
+				case change := <-changes:
+					watcher.log().Info("shared change", "name", watcher.Name, "build-groups", groups)
+					s.notify(change, slices.Clone(groups))
+				}
+			}
+		}()
+	}
+}
+
+// notify sends change to the named running groups without blocking on
+// groups that are busy building
+func (s *Supervisor) notify(change Change, names []string) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, name := range names {
+		g := s.groups[name]
+		if !g.running() {
+			continue
+		}
+
+		go func(restart chan Change, ctx context.Context) {
+			select {
+			case restart <- change:
+			case <-ctx.Done():
+			}
+		}(g.restart, g.ctx)
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// Sidecar is a companion process started alongside a build group's run
+// command, like a CSS watcher, and stopped with it on every restart and at
+// shutdown. Its output is prefixed with the group and sidecar names.
+//
+//	ex: {"name": "css", "runCmd": "npx", "runArgs": ["tailwindcss", "--watch"]}
+type Sidecar struct {
+	Name    string   `json:"name"`
+	RunCmd  string   `json:"runCmd"`
+	RunArgs []string `json:"runArgs,omitzero"`
+	RunEnv  []string `json:"runEnv,omitzero"`
+	RunDir  string   `json:"runDir,omitzero"`
+}
+
+// startSidecars launches every sidecar until ctx is cancelled, wg is done
+// once they have all exited
+//
+//	ex: b.startSidecars(ctx, &wg)
+func (b *Build) startSidecars(ctx context.Context, wg *sync.WaitGroup) {
+
+	for _, sidecar := range b.Sidecars {
+
+		// reuse Build for the command handling, logging and output prefix
+		sb := &Build{
+			Name:    b.Name + "/" + sidecar.Name,
+			RunCmd:  sidecar.RunCmd,
+			RunArgs: sidecar.RunArgs,
+			EnvFile: b.EnvFile,
+			Env:     b.Env,
+			RunEnv:  sidecar.RunEnv,
+			RunDir:  sidecar.RunDir,
+			Logger:  b.Logger,
+
+			PrefixOutput:  true,
+			ShutdownGrace: b.ShutdownGrace,
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sb.Run(ctx)
+		}()
+	}
+}
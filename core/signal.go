@@ -0,0 +1,95 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Some servers reload their config or assets in place on a signal, usually
+// SIGHUP, which is quicker than a rebuild and keeps connections open. With
+// ReloadMode "signal" a file change sends ReloadSignal to the running process
+// instead of rebuilding and restarting it. A step with ReloadMode "signal"
+// does the same for just the globs it covers, after running its command.
+
+// defaultReloadSignal is sent when ReloadSignal is not set
+const defaultReloadSignal = "SIGHUP"
+
+// reloadSignal returns the os.Signal named by ReloadSignal, the SIG prefix
+// is optional
+func (b *Build) reloadSignal() (os.Signal, error) {
+
+	name := strings.ToUpper(b.ReloadSignal)
+	if name == "" {
+		name = defaultReloadSignal
+	}
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	sig, ok := signals[name]
+	if !ok {
+		return nil, fmt.Errorf("build group %q: unknown or unsupported reloadSignal %q", b.Name, b.ReloadSignal)
+	}
+
+	return sig, nil
+}
+
+// checkReload rejects an unknown ReloadMode or an unusable ReloadSignal
+func (b *Build) checkReload() error {
+
+	signaled := b.ReloadMode == "signal"
+	for _, step := range b.Steps {
+		switch step.ReloadMode {
+		case "", "restart":
+		case "signal":
+			signaled = true
+		default:
+			return fmt.Errorf("build group %q: step %q has unknown reloadMode %q, expected restart or signal", b.Name, step.Name, step.ReloadMode)
+		}
+	}
+
+	switch b.ReloadMode {
+	case "", "restart", "signal":
+	default:
+		return fmt.Errorf("build group %q: unknown reloadMode %q, expected restart or signal", b.Name, b.ReloadMode)
+	}
+
+	if !signaled {
+		return nil
+	}
+
+	// the signal would only reach the local docker or ssh client
+	switch {
+	case b.Container != nil:
+		return fmt.Errorf("build group %q: reloadMode signal can't reach a process inside a container", b.Name)
+	case b.Remote != nil:
+		return fmt.Errorf("build group %q: reloadMode signal can't reach a process on a remote host", b.Name)
+	}
+
+	_, err := b.reloadSignal()
+	return err
+}
+
+// signalRun sends ReloadSignal to the run process through sigs, reporting
+// false when there is no process to signal and a restart is needed instead
+func (b *Build) signalRun(sigs chan<- os.Signal, running bool) bool {
+
+	if !running || sigs == nil {
+		return false
+	}
+
+	sig, err := b.reloadSignal()
+	if err != nil {
+		b.log().Error("reload signal", "name", b.Name, "error", err)
+		return false
+	}
+
+	b.log().Info("reload signal", "name", b.Name, "signal", sig)
+	select {
+	case sigs <- sig:
+	default: // one already pending will do
+	}
+
+	return true
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package core
+
+import (
+	"os"
+	"syscall"
+)
+
+// signals are the names accepted by ReloadSignal
+var signals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
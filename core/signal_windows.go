@@ -0,0 +1,7 @@
+package core
+
+import "os"
+
+// signals are the names accepted by ReloadSignal, windows can't deliver a
+// signal to another process so ReloadMode "signal" is unsupported
+var signals = map[string]os.Signal{}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// On a large tree every launch rebuilds even when nothing changed since the
+// tool last ran. With StateFile set, the file state a successful build was
+// made from is saved and the first build after a launch is skipped when the
+// tree still matches it and the build output is still there.
+
+// fileState is the saved state of the files a build was made from
+type fileState struct {
+	Patterns []string               `json:"patterns"`
+	Files    []map[string]time.Time `json:"files"` // per pattern, path => modtime
+}
+
+// snapshot converts a scan to a fileState
+func (b *Build) snapshot(scans []map[string]fs.FileInfo) fileState {
+
+	state := fileState{Patterns: b.patterns()}
+	for _, files := range scans {
+		modTimes := make(map[string]time.Time, len(files))
+		for path, file := range files {
+			modTimes[path] = file.ModTime()
+		}
+		state.Files = append(state.Files, modTimes)
+	}
+
+	return state
+}
+
+// saveState writes state to StateFile, errors are logged since a missing
+// state file only costs a rebuild
+func (b *Build) saveState(state fileState) {
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		b.log().Warn("stateFile", "name", b.Name, "error", err)
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(b.StateFile), 0o755)
+	if err == nil {
+		err = os.WriteFile(b.StateFile, data, 0o644)
+	}
+	if err != nil {
+		b.log().Warn("stateFile", "name", b.Name, "error", err)
+	}
+}
+
+// unchanged reports whether current matches the state saved in StateFile
+func (b *Build) unchanged(current fileState) bool {
+
+	data, err := os.ReadFile(b.StateFile)
+	if err != nil {
+		return false
+	}
+
+	var saved fileState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		b.log().Warn("stateFile", "name", b.Name, "error", err)
+		return false
+	}
+
+	same := slices.Equal(saved.Patterns, current.Patterns) &&
+		slices.EqualFunc(saved.Files, current.Files, func(a, b map[string]time.Time) bool {
+			return maps.EqualFunc(a, b, time.Time.Equal)
+		})
+	if !same {
+		return false
+	}
+
+	// a cleaned output directory would leave nothing to run
+	if path, missing := b.outputMissing(); missing {
+		b.log().Info("stateFile unchanged but the build output is missing, building", "name", b.Name, "path", path)
+		return false
+	}
+
+	return true
+}
+
+// outputMissing reports whether the build output, from OutDir or a -o in
+// BuildArgs, or the RunCmd artifact doesn't exist, returning the first path
+// missing. A remote build's output isn't on this machine to check.
+func (b *Build) outputMissing() (string, bool) {
+
+	if b.Remote != nil {
+		return "", false
+	}
+
+	var paths []string
+	if out, _, ok := b.outputPath(); ok {
+		paths = append(paths, out)
+	}
+	if path := b.artifactPath(); path != "" && !b.NoRun {
+		paths = append(paths, path)
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return path, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateFileNeedsBuildOutput(t *testing.T) {
+
+	writeTree(t, "main.go")
+
+	b := &Build{
+		Name:      "state",
+		Match:     []string{"*.go"},
+		StateFile: filepath.Join(".glr", "state.json"),
+		BuildArgs: []string{"build", "-o", "build/app"},
+		RunCmd:    "./build/app",
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	state := b.snapshot(b.scan(nil, &quietLog{log: b.log()}))
+	b.saveState(state)
+
+	if b.unchanged(state) {
+		t.Error("unchanged with the build output missing, want a build")
+	}
+
+	if err := os.MkdirAll("build", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("build", "app"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if !b.unchanged(state) {
+		t.Error("changed with the tree and build output as saved, want the build skipped")
+	}
+}
@@ -0,0 +1,268 @@
+package core
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StaticServer serves a directory of static files over HTTP
+type StaticServer struct {
+
+	// BindAddr is the IP and port to bind the static server to
+	// ex: ":8080"
+	BindAddr string `json:"bindAddr"`
+
+	// StaticDir is the relative path of the directory to serve
+	// ex: "wwwroot"
+	StaticDir string `json:"staticDir"`
+
+	// StaticMounts maps additional URL path prefixes to directories, each
+	// served with the prefix stripped
+	// ex: {"/assets/": "build/assets", "/docs/": "docs/html"}
+	StaticMounts map[string]string `json:"staticMounts,omitzero"`
+
+	// Placeholder serves a built-in page explaining the problem when a
+	// directory can't be opened, instead of leaving the port dead
+	Placeholder bool `json:"placeholder,omitzero"`
+
+	// ReadTimeout, WriteTimeout and IdleTimeout are passed to the
+	// http.Server, unset means no limit
+	// ex: "5s"
+	ReadTimeout  Duration `json:"readTimeout,omitzero"`
+	WriteTimeout Duration `json:"writeTimeout,omitzero"`
+	IdleTimeout  Duration `json:"idleTimeout,omitzero"`
+
+	// MaxHeaderBytes bounds the request headers, unset uses the net/http
+	// default of 1MB
+	MaxHeaderBytes int `json:"maxHeaderBytes,omitzero"`
+
+	// DisableKeepAlives closes every connection after one request, handy
+	// for reproducible benchmarks
+	DisableKeepAlives bool `json:"disableKeepAlives,omitzero"`
+
+	// H2C serves plaintext HTTP/2 to clients that speak it with prior
+	// knowledge alongside HTTP/1
+	H2C bool `json:"h2c,omitzero"`
+
+	// BasicAuth requires credentials for every request when set
+	BasicAuth *BasicAuth `json:"basicAuth,omitzero"`
+
+	// Precedence decides who answers when the static server shares the
+	// reverse proxy's listener: "proxy" (default) lets proxy routes win,
+	// "static" serves an existing static file first
+	Precedence string `json:"precedence,omitzero"`
+
+	// FallbackProxy is the reverseProxy path whose target answers requests
+	// that match neither a proxy route nor a static file, like a server
+	// rendering the remaining pages, when sharing the proxy's listener
+	// ex: "/"
+	FallbackProxy string `json:"fallbackProxy,omitzero"`
+
+	// Headers are added to every response, like the COOP and COEP headers
+	// WASM threads need
+	// ex: {"Cross-Origin-Opener-Policy": "same-origin", "Cross-Origin-Embedder-Policy": "require-corp"}
+	Headers map[string]string `json:"headers,omitzero"`
+}
+
+//go:embed static/missing.html
+var placeholderFS embed.FS
+
+// placeholder is the page served when StaticDir is missing
+var placeholder = template.Must(template.ParseFS(placeholderFS, "static/missing.html"))
+
+// RunStatic starts the static file server and blocks until ctx is cancelled
+// or the server fails. Files are read from disk on every request so changes
+// to StaticDir and StaticMounts are served immediately without a restart.
+//
+//	ex: go c.RunStatic(ctx)
+func (c *Config) RunStatic(ctx context.Context) {
+
+	s := c.StaticServer
+	log := c.log()
+
+	// the reverse proxy serves the mounts itself, see Config.router
+	if c.Combined() {
+		log.Info("static-server sharing the reverse-proxy listener", "bind", c.Bind)
+		return
+	}
+
+	log.Info("static-server init", "bindAddr", s.BindAddr, "staticDir", s.StaticDir, "staticMounts", s.StaticMounts)
+
+	site := s.site(log)
+	defer site.Close()
+	if site.served == 0 {
+		log.Error("static-server", "error", "nothing to serve")
+		return
+	}
+
+	server := &http.Server{
+		Addr:           s.BindAddr,
+		Handler:        s.BasicAuth.wrap(withHeaders(s.Headers, site), log),
+		ReadTimeout:    time.Duration(s.ReadTimeout),
+		WriteTimeout:   time.Duration(s.WriteTimeout),
+		IdleTimeout:    time.Duration(s.IdleTimeout),
+		MaxHeaderBytes: s.MaxHeaderBytes,
+		Protocols:      protocols(s.H2C),
+	}
+	server.SetKeepAlivesEnabled(!s.DisableKeepAlives)
+
+	// shutdown the server when the context is cancelled
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	log.Info("static-server listen", "addr", server.Addr)
+
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error("static-server", "error", err)
+		return
+	}
+
+	log.Info("static-server shutdown")
+}
+
+// Combined reports whether the static server shares the reverse proxy's
+// listener, which it does when its BindAddr is empty or matches Bind
+//
+//	ex: if config.Combined() { slog.Info("one origin") }
+func (c *Config) Combined() bool {
+	s := c.StaticServer
+	return s != nil && len(c.ReverseProxy) > 0 && (s.BindAddr == "" || s.BindAddr == c.Bind)
+}
+
+// mounts returns every URL prefix to directory mapping, StaticDir is mounted
+// at the root
+func (s *StaticServer) mounts() map[string]string {
+
+	mounts := make(map[string]string)
+
+	if s.StaticDir != "" {
+		mounts["/"] = s.StaticDir
+	}
+
+	for prefix, dir := range s.StaticMounts {
+		// a trailing slash makes the mux pattern match the whole subtree
+		if !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		if !strings.HasPrefix(prefix, "/") {
+			prefix = "/" + prefix
+		}
+		mounts[prefix] = dir
+	}
+
+	return mounts
+}
+
+// staticSite serves every mount of a StaticServer
+type staticSite struct {
+	http.Handler
+	roots  map[string]*os.Root // opened directories by mount prefix
+	served int                 // mounts being served, including placeholders
+}
+
+// site builds a mux serving every mount, call Close to release the opened
+// directories
+func (s *StaticServer) site(log *slog.Logger) *staticSite {
+
+	mux := http.NewServeMux()
+	site := &staticSite{Handler: mux, roots: make(map[string]*os.Root)}
+
+	for prefix, dir := range s.mounts() {
+
+		// os.Root keeps requests from escaping the directory
+		root, err := os.OpenRoot(filepath.FromSlash(dir))
+		switch {
+		case err == nil:
+			site.roots[prefix] = root
+			mux.Handle(prefix, http.StripPrefix(strings.TrimSuffix(prefix, "/"), http.FileServerFS(root.FS())))
+		case s.Placeholder:
+			log.Error("static-server directory missing, serving placeholder", "prefix", prefix, "dir", dir, "error", err)
+			mux.Handle(prefix, placeholderHandler(dir, err))
+		default:
+			log.Error("static-server directory missing, set placeholder to serve a notice instead", "prefix", prefix, "dir", dir, "error", err)
+			continue
+		}
+
+		log.Info("static-server mount", "prefix", prefix, "dir", dir)
+		site.served++
+	}
+
+	return site
+}
+
+// Close releases the opened directories
+func (site *staticSite) Close() {
+	for _, root := range site.roots {
+		root.Close()
+	}
+}
+
+// exists reports whether a request for urlPath would find a file, or a
+// directory with an index.html, under the longest matching mount
+func (site *staticSite) exists(urlPath string) bool {
+
+	prefix := ""
+	for p := range site.roots {
+		if strings.HasPrefix(urlPath, p) && len(p) > len(prefix) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return false
+	}
+
+	name := strings.TrimPrefix(path.Clean(strings.TrimPrefix(urlPath, strings.TrimSuffix(prefix, "/"))), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := site.roots[prefix].Stat(name)
+	if err != nil {
+		return false
+	}
+	if info.IsDir() {
+		_, err = site.roots[prefix].Stat(path.Join(name, "index.html"))
+		return err == nil
+	}
+
+	return true
+}
+
+// withHeaders sets headers on every response from next
+func withHeaders(headers map[string]string, next http.Handler) http.Handler {
+
+	if len(headers) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// placeholderHandler responds to every request with the placeholder page
+func placeholderHandler(staticDir string, cause error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		placeholder.Execute(w, struct {
+			StaticDir string
+			Error     string
+		}{staticDir, cause.Error()})
+	})
+}
@@ -0,0 +1,43 @@
+// go-live-reload client: reloads the page after each successful reload and
+// shows build errors in an overlay until the next successful build
+(() => {
+  const failures = new Map(); // build group => output of its failed build
+  let overlay;
+
+  function render() {
+    if (failures.size === 0) {
+      overlay?.remove();
+      overlay = undefined;
+      return;
+    }
+    if (!overlay) {
+      overlay = document.createElement("div");
+      overlay.style.cssText = "position:fixed;inset:0;z-index:2147483647;overflow:auto;padding:2em;" +
+        "background:rgba(20,20,20,0.92);color:#eee;font:14px/1.4 ui-monospace,monospace;";
+      overlay.title = "click to dismiss";
+      overlay.onclick = () => { overlay.remove(); overlay = undefined; };
+      document.body.appendChild(overlay);
+    }
+    overlay.replaceChildren();
+    for (const [name, output] of failures) {
+      const title = document.createElement("h2");
+      title.style.cssText = "color:#ff6b6b;font:bold 16px system-ui,sans-serif;margin:0 0 0.5em;";
+      title.textContent = "build failed: " + name;
+      const pre = document.createElement("pre");
+      pre.style.cssText = "white-space:pre-wrap;margin:0 0 2em;";
+      pre.textContent = output || "(no output)";
+      overlay.append(title, pre);
+    }
+  }
+
+  const events = new EventSource("/__glr/events");
+  events.onmessage = (e) => {
+    const msg = JSON.parse(e.data);
+    if (msg.type === "build") {
+      msg.ok ? failures.delete(msg.name) : failures.set(msg.name, msg.output);
+      render();
+    } else if (msg.type === "reload" && failures.size === 0) {
+      location.reload();
+    }
+  };
+})();
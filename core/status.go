@@ -0,0 +1,65 @@
+package core
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// Start records what each build group is doing so the reverse proxy can tell
+// a backend that is down for a rebuild from a broken one. With RebuildPage a
+// target answers the 502 it would otherwise return while its build group
+// isn't running with a 503 page that refreshes itself until it is back.
+
+// build group phases as shown on the rebuild page
+const (
+	phaseStarting   = "starting"
+	phaseRestarting = "restarting"
+	phaseBuilding   = "rebuilding"
+	phaseFailed     = "build failed"
+	phaseRunning    = "running"
+)
+
+// setPhase records the build group's current phase
+func (b *Build) setPhase(phase string) {
+	if b.groups != nil {
+		b.groups.phases.Store(b.Name, phase)
+	}
+}
+
+// phase returns a build group's current phase, false when the group isn't
+// running in this process
+func (g *groupState) phase(name string) (string, bool) {
+	phase, ok := g.phases.Load(name)
+	if !ok {
+		return "", false
+	}
+	return phase.(string), true
+}
+
+//go:embed static/rebuilding.html
+var rebuildingFS embed.FS
+
+// rebuilding is the page served while a build group is down
+var rebuilding = template.Must(template.ParseFS(rebuildingFS, "static/rebuilding.html"))
+
+// serveRebuilding answers with a 503 naming the group's phase, an HTML page
+// refreshing itself for browsers and plain text for everything else
+func serveRebuilding(w http.ResponseWriter, r *http.Request, group, phase string) {
+
+	w.Header().Set("Retry-After", "1")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Error(w, group+" "+phase, http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	rebuilding.Execute(w, struct {
+		Group string
+		Phase string
+	}{group, phase})
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"slices"
+)
+
+// BuildStep is a lighter build selected by which globs changed. When every
+// changed glob of a build group is listed in a step's Match, only the step's
+// command runs and the run process keeps going. A typical use is recompiling
+// assets a server reads from disk without a full go build and restart.
+//
+//	ex: {"name": "css", "match": ["css/*.css"], "buildCmd": "npm", "buildArgs": ["run", "css"]}
+type BuildStep struct {
+	Name string `json:"name,omitzero"`
+
+	// Match lists globs from the build group's Match, or its Extensions, that
+	// this step covers
+	Match []string `json:"match"`
+
+	BuildCmd  string   `json:"buildCmd,omitzero"`
+	BuildArgs []string `json:"buildArgs,omitzero"`
+	BuildEnv  []string `json:"buildEnv,omitzero"`
+	BuildDir  string   `json:"buildDir,omitzero"`
+
+	// ReloadMode "signal" sends the group's ReloadSignal to the run process
+	// after the command and "restart" restarts the run process without a
+	// build, either way the command may be left out
+	ReloadMode string `json:"reloadMode,omitzero"`
+}
+
+// stepFor returns the first step covering every glob in change, or nil when
+// a full rebuild is needed
+func (b *Build) stepFor(change Change) *BuildStep {
+
+	if len(change.Globs) == 0 {
+		return nil
+	}
+
+	for i := range b.Steps {
+		step := &b.Steps[i]
+		covered := true
+		for _, glob := range change.Globs {
+			if !slices.Contains(step.Match, glob) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return step
+		}
+	}
+
+	return nil
+}
+
+// runStep executes a step's command in place of the full build
+func (b *Build) runStep(ctx context.Context, step *BuildStep) {
+
+	b.log().Info("step execute", "name", b.Name, "step", step.Name)
+
+	// reuse Build for the command handling, logging and timing
+	sb := &Build{
+		Name:      b.Name + "/" + step.Name,
+		BuildCmd:  step.BuildCmd,
+		BuildArgs: step.BuildArgs,
+		EnvFile:   b.EnvFile,
+		Env:       b.Env,
+		BuildEnv:  step.BuildEnv,
+		BuildDir:  step.BuildDir,
+		Logger:    b.Logger,
+
+		BuildTimeout:  b.BuildTimeout,
+		ShutdownGrace: b.ShutdownGrace,
+		Timings:       b.Timings,
+	}
+
+	err := sb.BuildContext(ctx)
+	if err != nil {
+		b.log().Error("step", "name", b.Name, "step", step.Name, "error", err)
+	}
+}
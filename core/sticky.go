@@ -0,0 +1,153 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// A reverse proxy route can spread its requests over several upstreams by
+// listing more in Hosts, taking turns round-robin. A stateful backend keeping
+// sessions in memory loses them whenever a client lands on another instance,
+// so Sticky pins each client to the upstream it first reached with a cookie
+// the proxy sets on the response.
+
+// Sticky pins a client to one upstream of a route
+type Sticky struct {
+
+	// Cookie names the cookie holding the upstream's index, default
+	// "glr-upstream"
+	Cookie string `json:"cookie,omitzero"`
+
+	// MaxAge is how long the pin lasts, unset is a session cookie dropped
+	// when the browser closes
+	// ex: "1h"
+	MaxAge Duration `json:"maxAge,omitzero"`
+
+	// Secure marks the cookie as only sent over HTTPS
+	Secure bool `json:"secure,omitzero"`
+}
+
+// defaultStickyCookie names the cookie when Sticky doesn't
+const defaultStickyCookie = "glr-upstream"
+
+// balancer picks the upstream for each request to a route
+type balancer struct {
+	path      string
+	target    HttpTarget
+	groups    *groupState
+	upstreams []*url.URL
+	next      atomic.Uint64
+}
+
+// newBalancer parses the route's Host and Hosts
+//
+//	ex: lb, err := newBalancer("/api/", target, shared)
+func newBalancer(path string, target HttpTarget, groups *groupState) (*balancer, error) {
+
+	lb := &balancer{path: path, target: target, groups: groups}
+	for _, host := range append([]string{target.Host}, target.Hosts...) {
+		u, err := url.Parse(host)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", host, err)
+		}
+		lb.upstreams = append(lb.upstreams, u)
+	}
+
+	return lb, nil
+}
+
+// host returns the scheme and host of upstream i, the first following the
+// active blue/green port of the target's BuildGroup
+func (lb *balancer) host(i int) (string, string) {
+
+	u := lb.upstreams[i]
+	if port, ok := lb.groups.activePort(lb.target.BuildGroup); ok && i == 0 {
+		return u.Scheme, net.JoinHostPort(u.Hostname(), port)
+	}
+
+	return u.Scheme, u.Host
+}
+
+// pick points r at the upstream pinned by its cookie, or the next in turn
+func (lb *balancer) pick(r *http.Request) {
+
+	i := -1
+	if lb.target.Sticky != nil {
+		i = lb.pinned(r)
+	}
+	if i < 0 {
+		i = int((lb.next.Add(1) - 1) % uint64(len(lb.upstreams)))
+	}
+
+	r.URL.Scheme, r.URL.Host = lb.host(i)
+}
+
+// pinned returns the upstream index in r's cookie, or -1 when it has none
+// or it's out of range after Hosts changed
+func (lb *balancer) pinned(r *http.Request) int {
+
+	cookie, err := r.Cookie(lb.cookie())
+	if err != nil {
+		return -1
+	}
+
+	i, err := strconv.Atoi(cookie.Value)
+	if err != nil || i < 0 || i >= len(lb.upstreams) {
+		return -1
+	}
+
+	return i
+}
+
+// pin is the proxy's ModifyResponse, setting the cookie when the client
+// wasn't already pinned to the upstream that answered
+func (lb *balancer) pin(resp *http.Response) error {
+
+	if lb.target.Sticky == nil || len(lb.upstreams) < 2 {
+		return nil
+	}
+
+	for i := range lb.upstreams {
+		scheme, host := lb.host(i)
+		if scheme != resp.Request.URL.Scheme || host != resp.Request.URL.Host {
+			continue
+		}
+		if lb.pinned(resp.Request) == i {
+			return nil
+		}
+
+		// the cookie only applies below the route's path
+		path := "/"
+		if strings.HasPrefix(lb.path, "/") {
+			path = lb.path
+		}
+
+		cookie := &http.Cookie{
+			Name:     lb.cookie(),
+			Value:    strconv.Itoa(i),
+			Path:     path,
+			MaxAge:   int(time.Duration(lb.target.Sticky.MaxAge).Seconds()),
+			Secure:   lb.target.Sticky.Secure,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		}
+		resp.Header.Add("Set-Cookie", cookie.String())
+		return nil
+	}
+
+	return nil
+}
+
+// cookie returns the name of the sticky cookie
+func (lb *balancer) cookie() string {
+	if lb.target.Sticky.Cookie != "" {
+		return lb.target.Sticky.Cookie
+	}
+	return defaultStickyCookie
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stickyBalancer returns a sticky balancer over three upstreams for path
+func stickyBalancer(t *testing.T, path string) *balancer {
+	t.Helper()
+
+	lb, err := newBalancer(path, HttpTarget{
+		Host:   "http://localhost:8081",
+		Hosts:  []string{"http://localhost:8082", "http://localhost:8083"},
+		Sticky: &Sticky{MaxAge: Duration(time.Hour)},
+	}, newGroupState())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return lb
+}
+
+func TestStickyPinned(t *testing.T) {
+
+	lb := stickyBalancer(t, "/api/")
+
+	tests := []struct {
+		cookie string
+		want   int
+	}{
+		{"", -1},
+		{"0", 0},
+		{"2", 2},
+		{"3", -1},  // out of range after Hosts shrank
+		{"-1", -1}, // never set by us
+		{"two", -1},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: defaultStickyCookie, Value: tt.cookie})
+		}
+		if got := lb.pinned(r); got != tt.want {
+			t.Errorf("pinned with cookie %q = %d, want %d", tt.cookie, got, tt.want)
+		}
+	}
+}
+
+func TestStickyPick(t *testing.T) {
+
+	lb := stickyBalancer(t, "/api/")
+
+	// a pinned client always reaches its upstream
+	for range 3 {
+		r := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		r.AddCookie(&http.Cookie{Name: defaultStickyCookie, Value: "1"})
+		lb.pick(r)
+		if r.URL.Host != "localhost:8082" {
+			t.Errorf("pinned request sent to %s, want localhost:8082", r.URL.Host)
+		}
+	}
+
+	// an out of range pin falls back to taking turns
+	seen := map[string]bool{}
+	for range 3 {
+		r := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		r.AddCookie(&http.Cookie{Name: defaultStickyCookie, Value: "9"})
+		lb.pick(r)
+		seen[r.URL.Host] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("unpinned requests reached %v, want all three upstreams", seen)
+	}
+}
+
+func TestStickyPinCookie(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		path     string
+		cookie   string // the request's existing pin
+		host     string // the upstream that answered
+		want     string // the index pinned, empty when no cookie is set
+		wantPath string
+	}{
+		{"new client", "/api/", "", "localhost:8083", "2", "/api/"},
+		{"already pinned", "/api/", "2", "localhost:8083", "", ""},
+		{"pinned elsewhere", "/api/", "0", "localhost:8083", "2", "/api/"},
+		{"stale pin", "/api/", "7", "localhost:8082", "1", "/api/"},
+		{"host route", "example.test/", "", "localhost:8081", "0", "/"},
+		{"unknown upstream", "/api/", "", "localhost:9999", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			lb := stickyBalancer(t, tt.path)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: defaultStickyCookie, Value: tt.cookie})
+			}
+			req.URL.Scheme, req.URL.Host = "http", tt.host
+			resp := &http.Response{Request: req, Header: http.Header{}}
+
+			if err := lb.pin(resp); err != nil {
+				t.Fatal(err)
+			}
+
+			cookies := resp.Cookies()
+			if tt.want == "" {
+				if len(cookies) != 0 {
+					t.Errorf("cookie set: %v", cookies)
+				}
+				return
+			}
+			if len(cookies) != 1 {
+				t.Fatalf("got %d cookies, want 1", len(cookies))
+			}
+
+			c := cookies[0]
+			if c.Name != defaultStickyCookie || c.Value != tt.want || c.Path != tt.wantPath {
+				t.Errorf("cookie %s=%s path %s, want %s=%s path %s", c.Name, c.Value, c.Path, defaultStickyCookie, tt.want, tt.wantPath)
+			}
+			if !c.HttpOnly || c.MaxAge != 3600 {
+				t.Errorf("cookie HttpOnly %v MaxAge %d, want true and 3600", c.HttpOnly, c.MaxAge)
+			}
+		})
+	}
+}
+
+func TestStickyFollowsActivePort(t *testing.T) {
+
+	groups := newGroupState()
+	lb, err := newBalancer("/", HttpTarget{
+		Host:       "http://localhost:8081",
+		Hosts:      []string{"http://localhost:8082"},
+		BuildGroup: "api",
+		Sticky:     &Sticky{},
+	}, groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups.setActivePort("api", "8091")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: defaultStickyCookie, Value: "0"})
+	lb.pick(r)
+	if r.URL.Host != "localhost:8091" {
+		t.Errorf("pinned to the build group's upstream but sent to %s, want its active port", r.URL.Host)
+	}
+}
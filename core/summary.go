@@ -0,0 +1,60 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// SummaryTable renders one aligned row per build group describing what is
+// about to run, returned as lines so each can be logged on its own
+//
+//	ex: for _, line := range SummaryTable(builds) { slog.Info(line) }
+func SummaryTable(builds []Build) []string {
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "NAME\tHEARTBEAT\tGLOBS\tBUILD\tBUILD DIR\tRUN\tRUN DIR")
+
+	for _, b := range builds {
+		build, run := commandLine(b.BuildCmd, b.BuildArgs), commandLine(b.RunCmd, b.RunArgs)
+		if b.NoBuild {
+			build = "(noBuild)"
+		}
+		if b.NoRun {
+			run = "(noRun)"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			b.Name,
+			b.HeartBeat,
+			len(b.patterns()),
+			build,
+			orDash(b.BuildDir),
+			run,
+			orDash(b.RunDir),
+		)
+	}
+
+	w.Flush()
+
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+// commandLine joins a command and its arguments for display
+func commandLine(cmd string, args []string) string {
+	if cmd == "" {
+		return "-"
+	}
+	return strings.TrimSpace(cmd + " " + strings.Join(args, " "))
+}
+
+// orDash returns s or a dash when s is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
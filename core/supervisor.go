@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// Supervisor runs a set of build groups, each with its own context so they
+// can be started, stopped and reloaded by name while the others keep going
+type Supervisor struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	order  []string
+	groups map[string]*group
+	shared *groupState // handed to builds that have none, see groupstate.go
+
+	// Logger receives the supervisor's own log output, like the shutdown
+	// sequence and control socket, defaulting to slog.Default()
+	Logger *slog.Logger
+}
+
+// log returns the supervisor's Logger or slog.Default()
+func (s *Supervisor) log() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.Default()
+}
+
+// group is a single supervised build group
+type group struct {
+	build   *Build
+	restart chan Change
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{} // closed once Start and Watch have both returned
+}
+
+// GroupStatus reports the state of a supervised build group
+type GroupStatus struct {
+	Name    string
+	Running bool
+}
+
+// NewSupervisor returns a Supervisor for builds, the groups run until ctx is
+// cancelled. Call Start to launch them.
+//
+//	ex: s := NewSupervisor(ctx, config.Builds)
+func NewSupervisor(ctx context.Context, builds []Build) *Supervisor {
+
+	s := &Supervisor{
+		ctx:    ctx,
+		groups: make(map[string]*group),
+	}
+
+	// builds from a Config share its state with its reverse proxy, the rest
+	// share one of their own
+	for i := range builds {
+		if builds[i].groups != nil {
+			s.shared = builds[i].groups
+			break
+		}
+	}
+	if s.shared == nil {
+		s.shared = newGroupState()
+	}
+
+	for i := range builds {
+		if builds[i].groups == nil {
+			builds[i].groups = s.shared
+		}
+		name := builds[i].Name
+		s.order = append(s.order, name)
+		s.groups[name] = &group{build: &builds[i]}
+	}
+
+	return s
+}
+
+// Start launches the named build groups, or all of them if no names are
+// given. Groups that are already running are left alone.
+//
+//	ex: err := s.Start("frontend")
+func (s *Supervisor) Start(names ...string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(names) == 0 {
+		names = s.order
+	}
+
+	for _, name := range names {
+		g, ok := s.groups[name]
+		if !ok {
+			return fmt.Errorf("unknown build group %q", name)
+		}
+
+		if g.running() {
+			continue
+		}
+
+		// a stopped group must fully exit before it starts again
+		if g.done != nil {
+			<-g.done
+		}
+
+		g.ctx, g.cancel = context.WithCancel(s.ctx)
+		g.restart = make(chan Change)
+		g.done = make(chan struct{})
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			g.build.Start(g.ctx, g.restart) // start build and run loop for this build group
+		}()
+		go func() {
+			defer wg.Done()
+			g.build.Watch(g.ctx, g.restart) // watch for changes in this build group
+		}()
+		go func(done chan struct{}) {
+			wg.Wait()
+			close(done)
+		}(g.done)
+	}
+
+	return nil
+}
+
+// Stop cancels the named build groups, stopping their run processes
+//
+//	ex: err := s.Stop("frontend")
+func (s *Supervisor) Stop(names ...string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, name := range names {
+		g, ok := s.groups[name]
+		if !ok {
+			return fmt.Errorf("unknown build group %q", name)
+		}
+
+		if !g.running() {
+			continue
+		}
+
+		g.build.log().Info("stop", "build-group", name)
+		g.cancel()
+	}
+
+	return nil
+}
+
+// Reload sends a manual change to the named build groups, or all of them if
+// no names are given, without blocking on groups that are busy building
+//
+//	ex: err := s.Reload("frontend", "backend")
+func (s *Supervisor) Reload(names ...string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(names) == 0 {
+		names = s.order
+	}
+
+	for _, name := range names {
+		g, ok := s.groups[name]
+		if !ok {
+			return fmt.Errorf("unknown build group %q", name)
+		}
+
+		if !g.running() {
+			g.build.log().Warn("reload", "error", "build group is stopped", "build-group", name)
+			continue
+		}
+
+		g.build.log().Info("reload", "build-group", name)
+		go func(restart chan Change, ctx context.Context) {
+			select {
+			case restart <- Change{Manual: true}:
+			case <-ctx.Done():
+			}
+		}(g.restart, g.ctx)
+	}
+
+	return nil
+}
+
+// Status reports the state of every build group in config order
+//
+//	ex: for _, status := range s.Status() { ... }
+func (s *Supervisor) Status() []GroupStatus {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := []GroupStatus{}
+	for _, name := range s.order {
+		statuses = append(statuses, GroupStatus{Name: name, Running: s.groups[name].running()})
+	}
+
+	return statuses
+}
+
+// running reports whether the group has been started and not cancelled
+func (g *group) running() bool {
+	return g.ctx != nil && g.ctx.Err() == nil
+}
+
+// Shutdown stops every running group one at a time, waiting for each to exit
+// before moving on. Groups named in order go first and the rest follow in
+// reverse config order, so groups listed first (usually dependencies) stop
+// last.
+//
+//	ex: s.Shutdown(config.ShutdownOrder)
+func (s *Supervisor) Shutdown(order []string) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sequence := []string{}
+	for _, name := range order {
+		if _, ok := s.groups[name]; ok && !slices.Contains(sequence, name) {
+			sequence = append(sequence, name)
+		}
+	}
+	for _, name := range slices.Backward(s.order) {
+		if !slices.Contains(sequence, name) {
+			sequence = append(sequence, name)
+		}
+	}
+
+	s.log().Info("shutdown sequence", "build-groups", sequence)
+
+	for _, name := range sequence {
+		g := s.groups[name]
+		if g.done == nil {
+			continue
+		}
+
+		if g.running() {
+			g.build.log().Info("shutdown", "build-group", name)
+			g.cancel()
+		}
+
+		<-g.done
+		g.build.log().Info("shutdown complete", "build-group", name)
+	}
+}
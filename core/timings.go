@@ -0,0 +1,50 @@
+package core
+
+import "time"
+
+// Timings breaks each build down into its phases so the slow part of a
+// reload is easy to find:
+//
+//	prepare  resolving buildArgs (injectVersion runs git here), envFile and
+//	         the container or remote command
+//	command  the build command itself
+//	finish   moving an atomicOutput artifact into place
+//	report   picking errors or the -v package list out of the output
+//
+// Each step has its own line. The breakdown is logged at debug level, or at
+// info with timings set.
+
+// phaseTimer accumulates how long each phase of a build took
+type phaseTimer struct {
+	start  time.Time
+	last   time.Time
+	phases []any
+}
+
+// newPhaseTimer starts timing the first phase
+func newPhaseTimer() *phaseTimer {
+	now := time.Now()
+	return &phaseTimer{start: now, last: now}
+}
+
+// mark ends phase, the next one starts now
+func (t *phaseTimer) mark(phase string) {
+	now := time.Now()
+	t.phases = append(t.phases, phase, now.Sub(t.last))
+	t.last = now
+}
+
+// logTimings logs the phases marked so far and the total
+//
+//	ex: defer b.logTimings(timer)
+func (b *Build) logTimings(t *phaseTimer) {
+
+	args := append([]any{"name", b.Name}, t.phases...)
+	args = append(args, "total", time.Since(t.start))
+
+	if b.Timings {
+		b.log().Info("build timings", args...)
+		return
+	}
+	b.log().Debug("build timings", args...)
+}
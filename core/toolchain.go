@@ -0,0 +1,73 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Toolchain holds the defaults a build group's toolchain fills in for any of
+// BuildCmd, BuildArgs and Match the group leaves unset
+type Toolchain struct {
+	BuildCmd  string
+	BuildArgs []string
+	Match     []string
+}
+
+// Toolchains are the built-in profiles selectable with a build group's
+// toolchain field
+var Toolchains = map[string]Toolchain{
+	"go": {
+		BuildCmd:  "go",
+		BuildArgs: []string{"build", "-o", "build/"},
+		Match:     []string{"*.go", "go.mod", "go.sum"},
+	},
+	"make": {
+		BuildCmd: "make",
+		Match:    []string{"Makefile", "*.c", "*.h"},
+	},
+	"npm": {
+		BuildCmd:  "npm",
+		BuildArgs: []string{"run", "build"},
+		Match:     []string{"package.json", "src/*"},
+	},
+	"cargo": {
+		BuildCmd:  "cargo",
+		BuildArgs: []string{"build"},
+		Match:     []string{"Cargo.toml", "src/*.rs"},
+	},
+}
+
+// ResolveToolchains fills in defaults for every build group naming a
+// toolchain, values set in the config always win. An unknown toolchain is
+// an error.
+//
+//	ex: err := myConfig.ResolveToolchains()
+func (c *Config) ResolveToolchains() error {
+
+	for i := range c.Builds {
+		b := &c.Builds[i]
+
+		if b.Toolchain == "" {
+			continue
+		}
+
+		tc, ok := Toolchains[b.Toolchain]
+		if !ok {
+			return fmt.Errorf("builds[%d] %q: unknown toolchain %q", i, b.Name, b.Toolchain)
+		}
+
+		if b.BuildCmd == "" {
+			b.BuildCmd = tc.BuildCmd
+		}
+		if b.BuildArgs == nil {
+			b.BuildArgs = slices.Clone(tc.BuildArgs)
+		}
+		if b.Match == nil {
+			b.Match = slices.Clone(tc.Match)
+		}
+
+		b.log().Debug("toolchain", "name", b.Name, "toolchain", b.Toolchain, "buildCmd", b.BuildCmd, "buildArgs", b.BuildArgs, "match", b.Match)
+	}
+
+	return nil
+}
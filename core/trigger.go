@@ -0,0 +1,36 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TriggerFile is a sentinel path whose modtime changing forces a full reload
+// of the group, whatever its globs say, so an editor, Makefile or script can
+// reload it with a plain touch. It is stat'd on its own each heartbeat rather
+// than matched, and the reload counts as manual: it skips signal mode and
+// maxRestartsPerMinute just like --control-socket reloads.
+
+// triggered reports whether the trigger file was touched since the last call,
+// remembering its modtime in last. Creating the file counts as a touch,
+// removing it does not.
+//
+//	ex: if b.triggered(&triggerMod) { ... }
+func (b *Build) triggered(last *time.Time) bool {
+
+	if b.TriggerFile == "" {
+		return false
+	}
+
+	info, err := os.Stat(filepath.FromSlash(b.TriggerFile))
+	if err != nil {
+		*last = time.Time{}
+		return false
+	}
+
+	touched := !info.ModTime().Equal(*last)
+	*last = info.ModTime()
+
+	return touched
+}
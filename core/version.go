@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// defaultVersionVar and defaultCommitVar are the -X targets used by
+// InjectVersion when VersionVar and CommitVar are not set
+const (
+	defaultVersionVar = "main.version"
+	defaultCommitVar  = "main.commit"
+)
+
+// versionLDFlags resolves the current git tag and commit within BuildDir and
+// returns the -X flags injecting them into the configured variables
+//
+//	ex: "-X main.version=v0.0.2-3-gabc1234 -X main.commit=abc1234..."
+func (b *Build) versionLDFlags() string {
+
+	versionVar := b.VersionVar
+	if versionVar == "" {
+		versionVar = defaultVersionVar
+	}
+
+	commitVar := b.CommitVar
+	if commitVar == "" {
+		commitVar = defaultCommitVar
+	}
+
+	version := b.git("(devel)", "describe", "--tags", "--always", "--dirty")
+	commit := b.git("unknown", "rev-parse", "HEAD")
+
+	b.log().Debug("inject version", "name", b.Name, versionVar, version, commitVar, commit)
+
+	return fmt.Sprintf("-X %s=%s -X %s=%s", versionVar, version, commitVar, commit)
+}
+
+// git runs a git command in BuildDir returning its trimmed output, or
+// fallback if git is missing or this isn't a repository
+func (b *Build) git(fallback string, args ...string) string {
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = b.BuildDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		b.log().Warn("inject version", "name", b.Name, "git", args, "error", err)
+		return fallback
+	}
+
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// The reload webhook lets tools that can't reach the control socket, like a
+// sync daemon pushing into a remote container or a CI job, trigger reloads
+// over HTTP.
+//
+//	POST /reload                        reload every group
+//	POST /reload?group=frontend         reload the named groups, repeat
+//	POST /reload?group=frontend,backend group or separate names with commas
+//
+// With a token set requests must carry "Authorization: Bearer <token>".
+
+// ServeWebhook listens on addr for reload requests until ctx is cancelled
+//
+//	ex: go s.ServeWebhook(ctx, "127.0.0.1:9090", os.Getenv("RELOAD_TOKEN"))
+func (s *Supervisor) ServeWebhook(ctx context.Context, addr, token string) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+
+		if token != "" {
+			given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				s.log().Warn("reload-webhook unauthorized", "remote", r.RemoteAddr)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var names []string
+		for _, group := range r.URL.Query()["group"] {
+			for name := range strings.SplitSeq(group, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+
+		s.log().Info("reload-webhook", "remote", r.RemoteAddr, "build-groups", names)
+
+		err := s.Reload(names...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintln(w, "ok")
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	if token == "" {
+		s.log().Warn("reload-webhook has no token, anyone who can reach it can trigger reloads", "addr", addr)
+	}
+	s.log().Info("reload-webhook listen", "addr", addr)
+
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.log().Error("reload-webhook", "error", err)
+	}
+}
@@ -0,0 +1,44 @@
+// This example embeds the reloader in another Go program, rebuilding and
+// rerunning a webserver whenever a Go file changes until interrupted.
+//
+//	ex: go run ./examples/embed
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dearing/go-live-reload/core"
+)
+
+func main() {
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	builds := []core.Build{
+		{
+			Name:      "webserver",
+			Match:     []string{"*.go"},
+			HeartBeat: core.Duration(500 * time.Millisecond),
+			BuildCmd:  "go",
+			BuildArgs: []string{"build", "-o", "build/"},
+			RunCmd:    "./webserver",
+			RunDir:    "build",
+			Hooks: core.Hooks{
+				OnBuildDone: func(name string, err error, duration time.Duration) {
+					slog.Info("embed build done", "name", name, "error", err, "duration", duration)
+				},
+			},
+		},
+	}
+
+	err := core.Run(ctx, core.Options{Builds: builds})
+	if err != nil {
+		slog.Error("embed", "error", err)
+		os.Exit(1)
+	}
+}
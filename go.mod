@@ -3,3 +3,5 @@ module github.com/dearing/go-live-reload
 go 1.24.0
 
 tool github.com/dearing/go-live-reload
+
+require golang.org/x/crypto v0.48.0
@@ -3,23 +3,46 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"reflect"
 	"runtime/debug"
 	"slices"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/dearing/go-live-reload/core"
 )
 
 var argVersion = flag.Bool("version", false, "print debug info and exit")
 var argHeartBeat = flag.Duration("overwrite-heartbeat", 0, "temporarily overwrite all build group heartbeats")
+var argHeartBeats = flag.String("heartbeat", "", "override the heartbeat of specific build groups, like frontend=200ms,backend=2s")
+var argBuildArgsAppend = flag.String("build-args-append", "", "temporarily append arguments to all build group buildArgs")
+var argMaxRestarts = flag.Int("max-restarts-per-minute", 0, "pause any build group restarting more often than this (0 disables)")
+var argNoInitial = flag.Bool("no-initial", false, "start every build group idle until its first change, skipping the initial build and run")
+var argTimings = flag.Bool("timings", false, "log how long each phase of every build took at info level")
+var argPrefixOutput = flag.Bool("prefix-output", false, "prefix every line of build and run output with its build group")
+var argStrict = flag.Bool("strict", false, "treat config warnings like a match overlapping the build output as errors")
 var buildGroups = flag.String("build-groups", "", "comma separated list of build groups to run")
 var initConfig = flag.Bool("init-config", false, "initialize and save a new config file")
+var argPreset = flag.String("preset", "go", "preset used by --init-config (go, templ, node, static)")
+var argForce = flag.Bool("force", false, "allow --init-config to overwrite an existing config file")
+var argWatchConfig = flag.Bool("watch-config", false, "watch the config file and .glrignore, applying static server and ignore changes without restarting")
+var argControlSocket = flag.String("control-socket", "", "listen for commands on this unix socket, also used by the ctl subcommand")
+var argProfile = flag.String("profile", "", "merge this profile from the config file's profiles over the base config")
+var argReloadWebhookAddr = flag.String("reload-webhook-addr", "", "accept POST /reload?group=name requests on this address")
+var argReloadWebhookToken = flag.String("reload-webhook-token", "", "require this bearer token on reload webhook requests, defaults to $GLR_WEBHOOK_TOKEN")
+var argShutdownTimeout = flag.Duration("shutdown-timeout", 0, "exit anyway if stopping every build group takes longer than this (0 waits)")
 var configFile = flag.String("config-file", "go-live-reload.json", "load a config file")
 var logLevel = flag.String("log-level", "info", "log level (debug, info, warn, error)")
+var argEventsJSON = flag.String("events-json", "", "write newline-delimited JSON lifecycle events to this file descriptor number or file")
+var argSelfReload = flag.Bool("self-reload", false, "restart the tool with the same arguments when its own executable is replaced")
+var argPrintMatches = flag.Bool("print-matches", false, "print the files each selected build group watches and exit")
+var argQuiet = flag.Bool("quiet", false, "only log warnings and errors, and skip the startup warnings about defaults")
 
 func usage() {
 	println(`Usage: go-live-reload [options]
@@ -43,47 +66,184 @@ by Go's time.ParseDuration function. You can observe matches and duration with t
 
 ex: go-live-reload --overwrite-heartbeat=500ms --log-level=debug
 
+To tune just some build groups, --heartbeat takes group=duration pairs and leaves
+the rest at their config value, winning over --overwrite-heartbeat.
+
+ex: go-live-reload --heartbeat=frontend=200ms,backend=2s
+
 2) The --build-groups option is used to specify a comma separated list of build groups
 to run. If no build groups are specified, all build groups defined in the config
-will be ran. If no matches are found, the tool will exit with an error.
+will be ran. If no matches are found, the tool will exit with an error. Unknown
+names are logged with the closest configured names as a did-you-mean.
 
 ex: go-live-reload --build-groups=frontend,backend
 
-3) The ENV lists are appended to the current environment variables. If you need to
+3) The --preset option selects the starter config written by --init-config. Choose
+from go, templ, node or static to get match globs and build/run commands suited
+to that stack.
+
+ex: go-live-reload --init-config --preset=templ
+
+4) The --build-args-append option is used to temporarily append arguments to every
+build group's buildArgs without editing the config. The value is split on spaces
+and supports basic single and double quoting. Since the arguments are appended,
+they land after anything already in buildArgs.
+
+ex: go-live-reload --build-args-append="-race -tags 'dev debug'"
+
+5) The ENV lists are appended to the current environment variables. If you need to
 overwrite an environment variable, you can do so by specifying the same key in
 the ENV list. If you need to clear the environment, set the value to an empty list.
 Clearing and then appending is not supported by this tool.
 
+6) Signals: SIGINT and SIGTERM stop every build group and exit. SIGHUP rebuilds and
+restarts every build group without a file change, handy for scripts and when the
+tool runs detached. A second SIGINT or SIGTERM exits without waiting, and
+--shutdown-timeout bounds how long stopping may take under a process manager.
+
+ex: kill -HUP $(pgrep go-live-reload)
+ex: go-live-reload --shutdown-timeout=20s
+
+7) The --control-socket option listens on a unix socket for commands so editor
+plugins and scripts can drive the tool. The ctl subcommand sends one for you.
+Commands are reload [group...], stop group..., start group... and status.
+
+ex: go-live-reload --control-socket=glr.sock
+ex: go-live-reload --control-socket=glr.sock ctl reload frontend
+
+8) The --quiet option only logs warnings and errors and skips the startup warnings
+about using the default config file and running every build group. Errors from
+builds and run commands are still logged, handy under a process supervisor.
+
+ex: go-live-reload --quiet
+
+9) The --prefix-output option prefixes every line of build and run output with its
+build group's name, colored when writing to a terminal. A build group can pick
+its own "label" and "color" (red, green, yellow, blue, magenta, cyan) in the config.
+
+ex: go-live-reload --prefix-output
+
+10) The --events-json option writes a JSON line per lifecycle event (build_start,
+build_ok, build_fail, run_start, reload) with the build group name and a timestamp,
+separate from the human logs on stderr. Give it a file descriptor number or a file.
+
+ex: go-live-reload --events-json=3 3>events.ndjson
+
+11) The --self-reload option is for working on go-live-reload itself. When the
+running executable is replaced, say by go install, every build group is shut down
+and the new binary is started in place with the same arguments.
+
+ex: go-live-reload --self-reload
+
+12) The --print-matches option prints the files each selected build group watches,
+after ignore and the build output are left out, and exits. A quick way to confirm
+the watch set is what you expect and isn't thousands of files.
+
+ex: go-live-reload --print-matches --build-groups=frontend
+
+13) The --profile option merges one of the config's named "profiles" over the rest
+of the config, so a single file can hold dev, test and prod-like variations. The
+profile wins over the base config and command line options win over both.
+
+ex: go-live-reload --profile=test
+
+14) The --reload-webhook-addr option accepts POST /reload requests so sync tools and
+CI can trigger reloads over HTTP. Name groups with ?group=frontend, all reload if
+none are named. Set --reload-webhook-token or $GLR_WEBHOOK_TOKEN to require an
+"Authorization: Bearer <token>" header.
+
+ex: go-live-reload --reload-webhook-addr=127.0.0.1:9090
+ex: curl -X POST -H "Authorization: Bearer $TOKEN" "localhost:9090/reload?group=frontend"
+
+15) Exit codes: 0 after a clean exit, including SIGINT or SIGTERM, 1 for a failure
+while running like a shutdown timeout, 2 for an invalid config file or options and
+3 when there is nothing to run: no build groups were selected and there is no
+reverse proxy or static server to serve, or --build-groups named none that exist.
+
+ex: go-live-reload --build-groups=api || echo "exited with $?"
+
+16) The --no-initial option starts every build group idle: nothing is built or run
+until the group's first change, or a manual reload, so the tool can be left
+watching and only does work on the next edit. A stateFile instead skips just an
+unchanged first build and still runs the existing binary.
+
+ex: go-live-reload --no-initial
+
 Options:
 	`)
 	flag.PrintDefaults()
 }
 
+// exit codes, so scripts and CI can tell why the tool stopped
+const (
+	exitOK       = 0 // clean exit, including after SIGINT or SIGTERM
+	exitRuntime  = 1 // failed while running, like a shutdown timeout
+	exitConfig   = 2 // invalid config file or options, also used by flag
+	exitNoGroups = 3 // nothing to run, no build groups selected or servers
+)
+
 func main() {
+	os.Exit(run())
+}
+
+// run is the tool's main, returning the exit code
+func run() int {
 
 	// set our custom usage
 	flag.Usage = usage
 	flag.Parse()
 
-	// attempt set log level
-	slog.SetLogLoggerLevel(ParseLogLevel(*logLevel))
+	// attempt set log level, --quiet raises it to at least warn
+	level := ParseLogLevel(*logLevel)
+	if *argQuiet {
+		level = max(level, slog.LevelWarn)
+	}
+	slog.SetLogLoggerLevel(level)
 
 	// if --version is set, print version and exit
 	if *argVersion {
 		Version()
-		return
+		return exitOK
+	}
+
+	// if the ctl subcommand is used, send the command to a running instance and exit
+	if flag.Arg(0) == "ctl" {
+		if *argControlSocket == "" || flag.NArg() < 2 {
+			slog.Error("ctl", "error", "usage: go-live-reload --control-socket=path ctl <reload|stop|start|status> [build-group...]")
+			return exitConfig
+		}
+
+		err := core.Control(*argControlSocket, flag.Args()[1:], os.Stdout)
+		if err != nil {
+			slog.Error("ctl", "error", err)
+			return exitRuntime
+		}
+		return exitOK
 	}
 
 	// if --init-config is set, create a new config file and exit
 	if *initConfig {
-		c := core.NewConfig()
+
+		// refuse to clobber an existing config unless --force is set
+		if _, err := os.Stat(*configFile); err == nil && !*argForce {
+			slog.Error("init-config", "error", "config file exists, use --force to overwrite", "config", *configFile)
+			return exitConfig
+		}
+
+		preset, ok := core.Presets[*argPreset]
+		if !ok {
+			slog.Error("init-config", "error", "unknown preset", "preset", *argPreset, "presets", core.PresetNames())
+			return exitConfig
+		}
+
+		c := preset()
 		err := c.Save(*configFile)
 		if err != nil {
 			slog.Error("init-config", "error", err)
-			return
+			return exitConfig
 		}
-		slog.Info("init-config", "config", *configFile)
-		return
+		slog.Info("init-config", "config", *configFile, "preset", *argPreset)
+		return exitOK
 	}
 
 	config := &core.Config{}
@@ -91,11 +251,11 @@ func main() {
 	// if no config file is specified, exit
 	if *configFile == "" {
 		slog.Error("config-file", "error", "no config file specified")
-		return
+		return exitConfig
 	}
 
 	// if using the default config file, warn the user
-	if *configFile == "go-live-reload.json" {
+	if *configFile == "go-live-reload.json" && !*argQuiet {
 		slog.Warn("using default", "config-file", *configFile)
 	}
 
@@ -103,20 +263,76 @@ func main() {
 	err := config.Load(*configFile)
 	if err != nil {
 		slog.Error("config-file", "error", err)
-		return
+		return exitConfig
 	}
 
-	// check if reverse proxy is defined
-	if len(config.ReverseProxy) > 0 {
-		go config.RunProxy()
+	// load the watch exclusions shared by every group, if there are any
+	err = config.LoadIgnoreFile(core.IgnoreFile)
+	if err != nil {
+		slog.Error("ignore-file", "file", core.IgnoreFile, "error", err)
+		return exitConfig
 	}
 
-	// overwrite all heartBeats if --overwrite-heartbeat is set
-	if *argHeartBeat > 0 {
-		slog.Warn("overwrite-heartbeat", "duration", *argHeartBeat)
+	// if --profile is set, merge it over the base config before any flags
+	if *argProfile != "" {
+		err = config.ApplyProfile(*argProfile)
+		if err != nil {
+			slog.Error("profile", "error", err)
+			return exitConfig
+		}
+		slog.Info("profile", "name", *argProfile)
+	}
+
+	// a negative override would panic the watchers' tickers
+	if *argHeartBeat < 0 {
+		slog.Error("overwrite-heartbeat", "error", "duration must be positive", "duration", *argHeartBeat)
+		return exitConfig
+	}
+
+	// overwrite heartBeats if --overwrite-heartbeat or --heartbeat is set
+	heartBeats, err := parseHeartBeats(*argHeartBeats)
+	if err != nil {
+		slog.Error("heartbeat", "error", err)
+		return exitConfig
+	}
+	overrideHeartBeats(config.Builds, *argHeartBeat, heartBeats)
+
+	// overwrite all restart guards if --max-restarts-per-minute is set
+	if *argMaxRestarts > 0 {
+		slog.Warn("max-restarts-per-minute", "max", *argMaxRestarts)
 
 		for i := range config.Builds {
-			config.Builds[i].HeartBeat = *argHeartBeat
+			config.Builds[i].MaxRestartsPerMinute = *argMaxRestarts
+		}
+	}
+
+	// hold every group until its first change if --no-initial is set
+	if *argNoInitial {
+		for i := range config.Builds {
+			config.Builds[i].NoInitial = true
+		}
+	}
+
+	// break every build down into its phases if --timings is set
+	if *argTimings {
+		for i := range config.Builds {
+			config.Builds[i].Timings = true
+		}
+	}
+
+	// prefix all output if --prefix-output is set
+	if *argPrefixOutput {
+		for i := range config.Builds {
+			config.Builds[i].PrefixOutput = true
+		}
+	}
+
+	// append to all buildArgs if --build-args-append is set
+	if *argBuildArgsAppend != "" {
+		err := appendBuildArgs(config.Builds, *argBuildArgsAppend)
+		if err != nil {
+			slog.Error("build-args-append", "error", err)
+			return exitConfig
 		}
 	}
 
@@ -124,57 +340,286 @@ func main() {
 
 	// build list of groups to run
 	if *buildGroups != "" {
-		groups = strings.Split(*buildGroups, ",")
+		for group := range strings.SplitSeq(*buildGroups, ",") {
+			group = strings.TrimSpace(group)
+			if group != "" {
+				groups = append(groups, group)
+			}
+		}
 	}
 
 	// if no groups are defined, default to all
 	if len(groups) < 1 {
-		slog.Warn("no build-groups defined, defaulting to all")
+		if !*argQuiet {
+			slog.Warn("no build-groups defined, defaulting to all")
+		}
 	} else {
 		slog.Info("build-groups", "groups", groups)
 	}
 
 	slog.Info("ready", "config-file", *configFile)
 
-	// this will be the parent context for our build-groups
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	selected := selectGroups(config.Builds, groups)
 
-	builds := 0 // track our build count
-	// iterate over each build group and start the build and watch goroutines
+	// point out misspelled build groups with the closest names
+	var available []string
 	for _, build := range config.Builds {
+		available = append(available, build.Name)
+	}
+	for _, group := range groups {
+		if !slices.Contains(available, group) {
+			slog.Warn("unknown build group", "build-group", group, "did-you-mean", suggest(group, available))
+		}
+	}
 
-		// if groups are defined, skip any that are not in the list
-		if len(groups) != 0 && !slices.Contains(groups, build.Name) {
-			slog.Warn("skipping", "build-group", build.Name)
-			continue
+	// without builds keep serving the reverse proxy or static server, exit
+	// only when nothing is left to run or the named groups don't exist
+	if len(selected) == 0 {
+		serving := len(config.ReverseProxy) > 0 || config.StaticServer != nil
+		if len(groups) > 0 || !serving {
+			slog.Error("no builds found", "build-groups", *buildGroups, "config-file", *configFile, "available", available)
+			return exitNoGroups
 		}
+		slog.Info("no build groups, serving only", "reverseProxy", len(config.ReverseProxy) > 0, "staticServer", config.StaticServer != nil)
+	}
 
-		// start and watch the build group using the coordinating over the 'restart' channel
-		restart := make(chan struct{})
-		go build.Start(ctx, restart) // start build and run loop for this build group
-		go build.Watch(ctx, restart) // watch for changes in this build group
+	// if --events-json is set, report lifecycle events to wrappers
+	if *argEventsJSON != "" {
+		events, err := openEvents(*argEventsJSON)
+		if err != nil {
+			slog.Error("events-json", "error", err)
+			return exitConfig
+		}
+		defer events.Close()
 
-		builds++
+		hooks := core.EventHooks(events)
+		for i := range selected {
+			selected[i].Hooks = hooks
+		}
 	}
 
-	// if no builds are found, exit
-	if builds == 0 {
-		slog.Error("no builds found", "build-groups", *buildGroups, "config-file", *configFile)
-		return
+	// catch misconfigured build groups before anything starts
+	err = core.CheckBuilds(selected, *argStrict)
+	if err != nil {
+		slog.Error("check", "error", err)
+		return exitConfig
 	}
 
-	slog.Info("entering run loop", "build-groups", builds)
+	// if --print-matches is set, show what each group watches and exit
+	if *argPrintMatches {
+		for _, build := range selected {
+			matches := build.Matches()
+			fmt.Printf("%s (%d watched)\n", build.Name, len(matches))
+			for _, path := range matches {
+				fmt.Printf("  %s\n", path)
+			}
+		}
+		return exitOK
+	}
+
+	// summarize what is about to run
+	if len(selected) > 0 {
+		for _, line := range core.SummaryTable(selected) {
+			slog.Info(line)
+		}
+	}
+
+	// this will be the parent context for our servers and build-groups
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// everything is validated, start serving the reverse proxy if defined
+	if len(config.ReverseProxy) > 0 {
+		// only groups that run can hold requests until they are ready
+		names := []string{}
+		for _, build := range selected {
+			names = append(names, build.Name)
+		}
+		go config.RunProxy(names...)
+	}
+
+	// check if static server is defined
+	static := &staticRunner{}
+	static.Restart(ctx, config)
+
+	// if --watch-config is set, reload .glrignore and rebind the static server
+	// when its settings change
+	if *argWatchConfig {
+		go config.WatchIgnoreFile(ctx, core.IgnoreFile, time.Second)
+
+		changed := make(chan *core.Config)
+		go core.WatchConfig(ctx, *configFile, time.Second, changed, slog.Default())
+		go func() {
+			// the proxy keeps reading config and a static server its own
+			// config, so every restart is handed a fresh copy
+			current := *config
+			for next := range changed {
+				if *argProfile != "" {
+					if err := next.ApplyProfile(*argProfile); err != nil {
+						slog.Error("watch-config", "error", err)
+						continue
+					}
+				}
+				if reflect.DeepEqual(current.StaticServer, next.StaticServer) {
+					slog.Warn("watch-config", "error", "only staticServer changes are applied, restart to apply others")
+					continue
+				}
+				updated := current
+				updated.StaticServer = next.StaticServer
+
+				// the proxy built its routes once, it can't take on or hand
+				// back the static mounts while running
+				if current.Combined() || updated.Combined() {
+					slog.Warn("watch-config", "error", "staticServer shares the reverse-proxy listener, restart to apply its changes")
+					continue
+				}
+
+				slog.Info("watch-config", "staticServer", "restarting")
+				current = updated
+				static.Restart(ctx, &updated)
+			}
+		}()
+	}
+
+	// start the build and watch goroutines for each selected build group
+	supervisor := core.NewSupervisor(ctx, selected)
+	supervisor.Start()
+
+	// fan changes to shared globs out to the groups that depend on them
+	supervisor.WatchShared(config.SharedMatch)
+
+	// if --control-socket is set, accept commands from external tooling
+	if *argControlSocket != "" {
+		go supervisor.ServeControl(ctx, *argControlSocket)
+	}
+
+	// if --reload-webhook-addr is set, accept reloads over HTTP
+	if *argReloadWebhookAddr != "" {
+		token := *argReloadWebhookToken
+		if token == "" {
+			token = os.Getenv("GLR_WEBHOOK_TOKEN")
+		}
+		go supervisor.ServeWebhook(ctx, *argReloadWebhookAddr, token)
+	}
+
+	// if --self-reload is set, restart in place when our binary is replaced
+	var replaced <-chan string
+	if *argSelfReload {
+		replaced, err = core.WatchExecutable(ctx, time.Second, slog.Default())
+		if err != nil {
+			slog.Error("self-reload", "error", err)
+			return exitRuntime
+		}
+	}
+
+	slog.Info("entering run loop", "build-groups", len(selected))
 
 	chanSig := make(chan os.Signal, 1)
-	signal.Notify(chanSig, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(chanSig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	// block until we receive an interrupt signal, SIGHUP reloads all groups
+	for {
+		select {
+		case sig := <-chanSig:
+			if sig == syscall.SIGHUP {
+				slog.Info("reload signal received", "signal", sig)
+				supervisor.Reload()
+				continue
+			}
+
+			slog.Info("interrupt signal received", "signal", sig)
+			shutdown(supervisor, config.ShutdownOrder, chanSig)
+			cancel()
+			return exitOK
+		case path := <-replaced:
+			slog.Info("self-reload executable replaced", "path", path)
+			shutdown(supervisor, config.ShutdownOrder, chanSig)
+			cancel()
+
+			err := reexec(path)
+			slog.Error("self-reload", "error", err)
+			return exitRuntime
+		}
+	}
+}
+
+// shutdown stops every build group, giving up early and exiting non-zero when
+// --shutdown-timeout passes or another interrupt arrives, so a process
+// manager's stop is never held up indefinitely
+func shutdown(supervisor *core.Supervisor, order []string, signals <-chan os.Signal) {
+
+	done := make(chan struct{})
+	go func() {
+		supervisor.Shutdown(order)
+		close(done)
+	}()
 
-	// block until we receive an interrupt signal
-	for range chanSig {
-		slog.Info("interrupt signal received")
-		cancel()
+	var timeout <-chan time.Time
+	if *argShutdownTimeout > 0 {
+		timeout = time.After(*argShutdownTimeout)
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-timeout:
+			slog.Error("shutdown timed out, exiting with processes possibly still running", "timeout", *argShutdownTimeout)
+			os.Exit(exitRuntime)
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				continue
+			}
+			slog.Warn("second interrupt, exiting without waiting", "signal", sig)
+			os.Exit(exitRuntime)
+		}
+	}
+}
+
+// staticRunner owns the running static server so it can be restarted
+type staticRunner struct {
+	stop func() // cancels the running server and waits for it to exit
+}
+
+// Restart stops any running static server and starts a new one if the config
+// defines it
+func (s *staticRunner) Restart(ctx context.Context, config *core.Config) {
+
+	if s.stop != nil {
+		s.stop()
+		s.stop = nil
+	}
+
+	if config.StaticServer == nil {
 		return
 	}
+
+	staticCtx, staticCancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		config.RunStatic(staticCtx)
+		close(done)
+	}()
+
+	s.stop = func() {
+		staticCancel()
+		<-done
+	}
+}
+
+// openEvents opens the --events-json target, a number is taken as an already
+// open file descriptor such as 1 for stdout or 3 for a pipe set up by a wrapper
+func openEvents(target string) (*os.File, error) {
+
+	if fd, err := strconv.Atoi(target); err == nil {
+		if fd < 0 {
+			return nil, fmt.Errorf("invalid file descriptor %d", fd)
+		}
+		return os.NewFile(uintptr(fd), "events-json"), nil
+	}
+
+	return os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
 }
 
 // version retrieves the build information and logs it
@@ -196,6 +641,110 @@ func Version() {
 	}
 }
 
+// overrideHeartBeats sets every group's heartBeat to all when it is positive,
+// then the groups named in perGroup, which win over all. Groups left without
+// a heartBeat fall back in Watch.
+//
+//	ex: overrideHeartBeats(config.Builds, 0, map[string]time.Duration{"api": time.Second})
+func overrideHeartBeats(builds []core.Build, all time.Duration, perGroup map[string]time.Duration) {
+
+	if all > 0 {
+		slog.Warn("overwrite-heartbeat", "duration", all)
+
+		for i := range builds {
+			builds[i].HeartBeat = core.Duration(all)
+		}
+	}
+
+	var available []string
+	for i := range builds {
+		available = append(available, builds[i].Name)
+		if beat, ok := perGroup[builds[i].Name]; ok {
+			slog.Warn("heartbeat", "build-group", builds[i].Name, "duration", beat)
+			builds[i].HeartBeat = core.Duration(beat)
+		}
+	}
+
+	for name := range perGroup {
+		if !slices.Contains(available, name) {
+			slog.Warn("heartbeat unknown build group", "build-group", name, "did-you-mean", suggest(name, available))
+		}
+	}
+}
+
+// appendBuildArgs splits value like a shell and appends it to every group's
+// buildArgs, after anything the config set so the flag has the last word
+//
+//	ex: err := appendBuildArgs(config.Builds, `-tags "dev debug"`)
+func appendBuildArgs(builds []core.Build, value string) error {
+
+	extra, err := core.SplitArgs(value)
+	if err != nil {
+		return err
+	}
+
+	slog.Warn("build-args-append", "args", extra)
+
+	// clone so appending never writes into an array another slice shares
+	for i := range builds {
+		builds[i].BuildArgs = append(slices.Clone(builds[i].BuildArgs), extra...)
+	}
+
+	return nil
+}
+
+// selectGroups returns copies of the builds named in groups, in config order,
+// or all of them when groups is empty
+//
+//	ex: selected := selectGroups(config.Builds, []string{"frontend"})
+func selectGroups(builds []core.Build, groups []string) []core.Build {
+
+	var selected []core.Build
+	for _, build := range builds {
+
+		// if groups are defined, skip any that are not in the list
+		if len(groups) != 0 && !slices.Contains(groups, build.Name) {
+			slog.Warn("skipping", "build-group", build.Name)
+			continue
+		}
+
+		selected = append(selected, build)
+	}
+
+	return selected
+}
+
+// parseHeartBeats reads a comma separated list of group=duration pairs
+//
+//	ex: parseHeartBeats("frontend=200ms,backend=2s")
+func parseHeartBeats(value string) (map[string]time.Duration, error) {
+
+	heartBeats := make(map[string]time.Duration)
+	for pair := range strings.SplitSeq(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, duration, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("%q is not group=duration", pair)
+		}
+
+		beat, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil {
+			return nil, fmt.Errorf("build group %q: %w", name, err)
+		}
+		if beat <= 0 {
+			return nil, fmt.Errorf("build group %q: duration must be positive", name)
+		}
+
+		heartBeats[strings.TrimSpace(name)] = beat
+	}
+
+	return heartBeats, nil
+}
+
 // parseLogLevel converts a string to a slog.Level
 func ParseLogLevel(value string) slog.Level {
 
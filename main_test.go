@@ -0,0 +1,127 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dearing/go-live-reload/core"
+)
+
+func TestOverrideHeartBeatsSelectedGroups(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		all      time.Duration
+		perGroup map[string]time.Duration
+		groups   []string
+		want     map[string]core.Duration
+	}{
+		{
+			name:   "no overrides keeps config and unset",
+			groups: []string{"api", "unset"},
+			want:   map[string]core.Duration{"api": core.Duration(2 * time.Second), "unset": 0},
+		},
+		{
+			name:   "overwrite applies to selected groups",
+			all:    500 * time.Millisecond,
+			groups: []string{"web", "unset"},
+			want:   map[string]core.Duration{"web": core.Duration(500 * time.Millisecond), "unset": core.Duration(500 * time.Millisecond)},
+		},
+		{
+			name:     "per group wins over overwrite",
+			all:      500 * time.Millisecond,
+			perGroup: map[string]time.Duration{"api": 100 * time.Millisecond, "missing": time.Second},
+			groups:   []string{"api", "web"},
+			want:     map[string]core.Duration{"api": core.Duration(100 * time.Millisecond), "web": core.Duration(500 * time.Millisecond)},
+		},
+		{
+			name:     "per group for an unselected group",
+			perGroup: map[string]time.Duration{"web": 100 * time.Millisecond},
+			groups:   []string{"api"},
+			want:     map[string]core.Duration{"api": core.Duration(2 * time.Second)},
+		},
+		{
+			name:     "no groups selects all",
+			perGroup: map[string]time.Duration{"unset": 300 * time.Millisecond},
+			want:     map[string]core.Duration{"api": core.Duration(2 * time.Second), "web": core.Duration(time.Second), "unset": core.Duration(300 * time.Millisecond)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			builds := []core.Build{
+				{Name: "api", HeartBeat: core.Duration(2 * time.Second)},
+				{Name: "web", HeartBeat: core.Duration(time.Second)},
+				{Name: "unset"},
+			}
+
+			overrideHeartBeats(builds, tt.all, tt.perGroup)
+			selected := selectGroups(builds, tt.groups)
+
+			if len(selected) != len(tt.want) {
+				t.Fatalf("selected %d groups, want %d", len(selected), len(tt.want))
+			}
+			for _, build := range selected {
+				want, ok := tt.want[build.Name]
+				if !ok {
+					t.Errorf("selected unexpected group %q", build.Name)
+					continue
+				}
+				if build.HeartBeat != want {
+					t.Errorf("group %q heartBeat = %s, want %s", build.Name, build.HeartBeat, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseHeartBeats(t *testing.T) {
+
+	got, err := parseHeartBeats(" api=200ms, web = 2s ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["api"] != 200*time.Millisecond || got["web"] != 2*time.Second || len(got) != 2 {
+		t.Errorf("parseHeartBeats = %v", got)
+	}
+
+	for _, bad := range []string{"api", "=1s", "api=soon", "api=0s", "api=-1s"} {
+		if _, err := parseHeartBeats(bad); err == nil {
+			t.Errorf("parseHeartBeats(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestAppendBuildArgs(t *testing.T) {
+
+	shared := []string{"build", "-o", "build/", "extra"}
+	builds := []core.Build{
+		{Name: "api", BuildArgs: shared[:3]},
+		{Name: "web", BuildArgs: shared[:3]},
+		{Name: "empty"},
+	}
+
+	err := appendBuildArgs(builds, `-tags "dev debug" -race`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"build", "-o", "build/", "-tags", "dev debug", "-race"}
+	for _, build := range builds[:2] {
+		if !slices.Equal(build.BuildArgs, want) {
+			t.Errorf("group %q buildArgs = %q, want %q", build.Name, build.BuildArgs, want)
+		}
+	}
+	if want := []string{"-tags", "dev debug", "-race"}; !slices.Equal(builds[2].BuildArgs, want) {
+		t.Errorf("group without buildArgs = %q, want %q", builds[2].BuildArgs, want)
+	}
+	if shared[3] != "extra" {
+		t.Errorf("appending wrote into the shared array: %q", shared)
+	}
+
+	if err := appendBuildArgs(builds, `"open`); err == nil {
+		t.Error("unterminated quote accepted")
+	}
+}
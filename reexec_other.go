@@ -0,0 +1,14 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// reexec replaces the running process with path, keeping the arguments and
+// environment so the config and flags carry over
+func reexec(path string) error {
+	return syscall.Exec(path, os.Args, os.Environ())
+}
@@ -0,0 +1,8 @@
+package main
+
+import "errors"
+
+// reexec is unsupported on windows, which can't replace a running process
+func reexec(path string) error {
+	return errors.New("self-reload is not supported on windows")
+}
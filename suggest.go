@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// suggest returns the names within a couple of edits of name, closest first,
+// to turn a misspelled --build-groups entry into a did-you-mean
+func suggest(name string, names []string) []string {
+
+	// allow more slack for longer names, but never a complete rewrite
+	limit := max(1, min(3, len(name)/3))
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	candidates := []candidate{}
+	for _, n := range names {
+		d := editDistance(strings.ToLower(name), strings.ToLower(n))
+		if d <= limit {
+			candidates = append(candidates, candidate{n, d})
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return a.distance - b.distance })
+
+	suggestions := []string{}
+	for _, c := range candidates {
+		suggestions = append(suggestions, c.name)
+	}
+
+	return suggestions
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}